@@ -0,0 +1,42 @@
+package elgamal
+
+import "math/big"
+
+// Aggregator computes the homomorphic product of many ciphers like
+// HomomorphicEncMultiple, but it keeps only the running product instead
+// of all ciphers, so that it can aggregate ciphers as they arrive.
+type Aggregator struct {
+	pub    *PublicKey
+	c1, c2 *big.Int
+}
+
+// NewAggregator returns an Aggregator of ciphers under pub. The result of
+// an Aggregator without any ciphers is (1, 1).
+func NewAggregator(pub *PublicKey) *Aggregator {
+	return &Aggregator{
+		pub: pub,
+		c1:  new(big.Int).Set(one),
+		c2:  new(big.Int).Set(one),
+	}
+}
+
+// Add multiplies the cipher (c1, c2) into the running product. It returns
+// ErrCipherLarge and leaves the product unchanged if the cipher is invalid.
+func (a *Aggregator) Add(c1, c2 []byte) error {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(a.pub.P) >= 0 || cipher2.Cmp(a.pub.P) >= 0 { //  (c1, c2) < P
+		return ErrCipherLarge
+	}
+
+	// C1 = C1 * c1 mod p
+	mulMod(a.c1, a.c1, cipher1, a.pub.P)
+	// C2 = C2 * c2 mod p
+	mulMod(a.c2, a.c2, cipher2, a.pub.P)
+	return nil
+}
+
+// Result returns the homomorphic product of all ciphers added so far.
+func (a *Aggregator) Result() ([]byte, []byte) {
+	return a.c1.Bytes(), a.c2.Bytes()
+}
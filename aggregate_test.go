@@ -0,0 +1,38 @@
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	priv := newTestKey(t)
+	agg := NewAggregator(&priv.PublicKey)
+	if c1, c2 := agg.Result(); !bytes.Equal(c1, []byte{1}) || !bytes.Equal(c2, []byte{1}) {
+		t.Errorf("empty aggregator returned (%x, %x), want (1, 1)", c1, c2)
+	}
+
+	var ciphertexts [][2][]byte
+	for i := 2; i < 9; i++ {
+		c1, c2, err := priv.Encrypt([]byte{byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertexts = append(ciphertexts, [2][]byte{c1, c2})
+		if err := agg.Add(c1, c2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := agg.Add(priv.P.Bytes(), []byte{1}); err != ErrCipherLarge {
+		t.Errorf("got %v, want ErrCipherLarge", err)
+	}
+
+	a1, a2 := agg.Result()
+	b1, b2, err := priv.HomomorphicEncMultiple(ciphertexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a1, b1) || !bytes.Equal(a2, b2) {
+		t.Error("aggregate differs from HomomorphicEncMultiple")
+	}
+}
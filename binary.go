@@ -0,0 +1,179 @@
+package elgamal
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync/atomic"
+)
+
+var (
+	errBinaryTruncated = errors.New("elgamal: binary data is truncated")
+	errBinaryEmptyInt  = errors.New("elgamal: binary data contains an empty integer")
+	errBinaryOrder     = errors.New("elgamal: binary data contains an order Q with P != 2Q + 1")
+)
+
+// appendBytes appends b to buf as a 4-byte big-endian length
+// followed by the bytes of b.
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(b)))
+	return append(buf, b...)
+}
+
+// readBytes reads a length-prefixed byte slice written by appendBytes and
+// returns it along with the remaining bytes. It returns an error if the
+// declared length exceeds the available bytes.
+func readBytes(buf []byte) ([]byte, []byte, error) {
+	if len(buf) < 4 {
+		return nil, nil, errBinaryTruncated
+	}
+	n := binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+	if uint64(n) > uint64(len(buf)) {
+		return nil, nil, errBinaryTruncated
+	}
+	return buf[:n], buf[n:], nil
+}
+
+// appendInt appends x to buf as a 4-byte big-endian length
+// followed by the big-endian bytes of x.
+func appendInt(buf []byte, x *big.Int) []byte {
+	return appendBytes(buf, x.Bytes())
+}
+
+// readInt reads a length-prefixed integer written by appendInt and returns
+// it along with the remaining bytes. It returns an error if the declared
+// length exceeds the available bytes or is zero, since no key parameter
+// may be zero.
+func readInt(buf []byte) (*big.Int, []byte, error) {
+	b, rest, err := readBytes(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil, errBinaryEmptyInt
+	}
+	return new(big.Int).SetBytes(b), rest, nil
+}
+
+// readInts reads all length-prefixed integers written by appendInt.
+func readInts(data []byte) ([]*big.Int, error) {
+	var ints []*big.Int
+	for len(data) > 0 {
+		x, rest, err := readInt(data)
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, x)
+		data = rest
+	}
+	return ints, nil
+}
+
+// isSafeOrder reports whether p = 2q + 1.
+func isSafeOrder(p, q *big.Int) bool {
+	return p.Bit(0) == 1 && new(big.Int).Rsh(p, 1).Cmp(q) == 0
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The
+// parameters P, G, Y and Q are each encoded as a 4-byte big-endian length
+// followed by the big-endian bytes of the integer.
+func (pub *PublicKey) MarshalBinary() ([]byte, error) {
+	if pub.P == nil || pub.G == nil || pub.Y == nil {
+		return nil, errors.New("elgamal: missing public key parameters")
+	}
+
+	var buf []byte
+	buf = appendInt(buf, pub.P)
+	buf = appendInt(buf, pub.G)
+	buf = appendInt(buf, pub.Y)
+	buf = appendInt(buf, pub.Order())
+	return buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It returns an error if a declared length exceeds the available bytes
+// or if an integer is empty.
+// Data without Q, as written by earlier versions, is accepted as well.
+// Since a private key written by earlier versions, P, G, Y and X, has as
+// many fields as a public key with Q, the fourth field is only accepted
+// as Q if P = 2Q + 1.
+func (pub *PublicKey) UnmarshalBinary(data []byte) error {
+	fields, err := readInts(data)
+	if err != nil {
+		return err
+	}
+
+	var q *big.Int
+	switch len(fields) {
+	case 3:
+	case 4:
+		q = fields[3]
+		if !isSafeOrder(fields[0], q) {
+			return errBinaryOrder
+		}
+	default:
+		return errors.New("elgamal: invalid number of public key parameters")
+	}
+
+	pub.P = fields[0]
+	pub.G = fields[1]
+	pub.Y = fields[2]
+	pub.Q = q
+	pub.precomputed = nil
+	pub.lazyQ = atomic.Value{}
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The
+// public key parameters are encoded as in PublicKey.MarshalBinary and
+// followed by the length-prefixed secret exponent X.
+func (priv *PrivateKey) MarshalBinary() ([]byte, error) {
+	buf, err := priv.PublicKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if priv.X == nil {
+		return nil, errors.New("elgamal: missing private key parameters")
+	}
+	return appendInt(buf, priv.X), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It returns an error if the decoded key does not satisfy y = g^x mod p,
+// so that corrupted data is detected early. Data without Q, as written
+// by earlier versions, is accepted as well.
+func (priv *PrivateKey) UnmarshalBinary(data []byte) error {
+	fields, err := readInts(data)
+	if err != nil {
+		return err
+	}
+
+	var q, x *big.Int
+	switch len(fields) {
+	case 4:
+		x = fields[3]
+	case 5:
+		q, x = fields[3], fields[4]
+		if !isSafeOrder(fields[0], q) {
+			return errBinaryOrder
+		}
+	default:
+		return errors.New("elgamal: invalid number of private key parameters")
+	}
+
+	p, g, y := fields[0], fields[1], fields[2]
+	// y = g^x mod p
+	if p.Sign() == 0 || new(big.Int).Exp(g, x, p).Cmp(y) != 0 {
+		return errors.New("elgamal: private key does not match public key")
+	}
+
+	priv.PublicKey = PublicKey{
+		G: g,
+		P: p,
+		Y: y,
+		Q: q,
+	}
+	priv.X = x
+	return nil
+}
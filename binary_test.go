@@ -0,0 +1,143 @@
+package elgamal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"math/big"
+	"testing"
+)
+
+func TestPublicKeyBinaryRoundTrip(t *testing.T) {
+	pub := &newTestKey(t).PublicKey
+	data, err := pub.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got PublicKey
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(pub) || got.Q.Cmp(pub.Order()) != 0 {
+		t.Error("public key changed in the binary round trip")
+	}
+}
+
+func TestPublicKeyGob(t *testing.T) {
+	pub := &newTestKey(t).PublicKey
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(pub); err != nil {
+		t.Fatal(err)
+	}
+	var got PublicKey
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(pub) {
+		t.Error("public key changed in the gob round trip")
+	}
+}
+
+func TestPublicKeyUnmarshalBinaryLength(t *testing.T) {
+	data, err := newTestKey(t).PublicKey.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// declare one byte more for P than the buffer holds
+	bad := bytes.Clone(data)
+	binary.BigEndian.PutUint32(bad, uint32(len(data)))
+	if err := new(PublicKey).UnmarshalBinary(bad); err != errBinaryTruncated {
+		t.Errorf("oversized length: got %v, want errBinaryTruncated", err)
+	}
+	if err := new(PublicKey).UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("accepted truncated data")
+	}
+	if err := new(PublicKey).UnmarshalBinary(data[:3]); err != errBinaryTruncated {
+		t.Errorf("short length prefix: got %v, want errBinaryTruncated", err)
+	}
+}
+
+func TestPublicKeyUnmarshalBinaryLegacyPrivateKey(t *testing.T) {
+	priv := newTestKey(t)
+	// P, G, Y, X as written for private keys by earlier versions
+	var legacy []byte
+	for _, x := range []*big.Int{priv.P, priv.G, priv.Y, priv.X} {
+		legacy = appendInt(legacy, x)
+	}
+	if err := new(PublicKey).UnmarshalBinary(legacy); err != errBinaryOrder {
+		t.Errorf("legacy private key: got %v, want errBinaryOrder", err)
+	}
+	var got PrivateKey
+	if err := got.UnmarshalBinary(legacy); err != nil || !got.Equal(priv) {
+		t.Errorf("legacy private key does not load as private key: %v", err)
+	}
+
+	// Q = (p+1)/2
+	var bad []byte
+	for _, x := range []*big.Int{priv.P, priv.G, priv.Y, new(big.Int).Add(priv.Order(), one), priv.X} {
+		bad = appendInt(bad, x)
+	}
+	if err := new(PrivateKey).UnmarshalBinary(bad); err != errBinaryOrder {
+		t.Errorf("private key with wrong Q: got %v, want errBinaryOrder", err)
+	}
+}
+
+func TestPrivateKeyBinaryRoundTrip(t *testing.T) {
+	priv := newTestKey(t)
+	data, err := priv.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got PrivateKey
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) {
+		t.Error("private key changed in the binary round trip")
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(priv); err != nil {
+		t.Fatal(err)
+	}
+	var dec PrivateKey
+	if err := gob.NewDecoder(&buf).Decode(&dec); err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Equal(priv) {
+		t.Error("private key changed in the gob round trip")
+	}
+}
+
+func TestPrivateKeyUnmarshalBinaryCorrupt(t *testing.T) {
+	data, err := newTestKey(t).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flipping the lowest bit of x breaks y = g^x mod p
+	bad := bytes.Clone(data)
+	bad[len(bad)-1] ^= 1
+	if err := new(PrivateKey).UnmarshalBinary(bad); err == nil {
+		t.Error("accepted a corrupted secret exponent")
+	}
+	if err := new(PrivateKey).UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("accepted truncated data")
+	}
+	if err := new(PrivateKey).UnmarshalBinary(nil); err == nil {
+		t.Error("accepted empty data")
+	}
+}
+
+func TestUnmarshalBinaryEmptyInt(t *testing.T) {
+	// P = 2, G is empty, Y = 3
+	data := []byte{0, 0, 0, 1, 2, 0, 0, 0, 0, 0, 0, 0, 1, 3}
+	if err := new(PublicKey).UnmarshalBinary(data); err != errBinaryEmptyInt {
+		t.Errorf("public key: got %v, want errBinaryEmptyInt", err)
+	}
+	if err := new(PrivateKey).UnmarshalBinary(append(data, 0, 0, 0, 1, 5)); err != errBinaryEmptyInt {
+		t.Errorf("private key: got %v, want errBinaryEmptyInt", err)
+	}
+	if err := new(PublicKey).UnmarshalBinary([]byte{0xff, 0xff, 0xff, 0xff, 1}); err != errBinaryTruncated {
+		t.Errorf("length of 2^32-1: got %v, want errBinaryTruncated", err)
+	}
+}
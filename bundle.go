@@ -0,0 +1,186 @@
+package elgamal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptedPrivateKeyPEMType is the PEM block type of the passphrase
+// encrypted secret exponent of a key bundle.
+const EncryptedPrivateKeyPEMType = "ELGAMAL ENCRYPTED PRIVATE KEY"
+
+var ErrBundleAuth = errors.New("elgamal: key bundle authentication failed")
+
+var errBundleScryptParams = errors.New("elgamal: invalid key bundle scrypt parameters")
+
+// The scrypt parameters of new bundles follow the recommendation of
+// golang.org/x/crypto/scrypt for interactive logins. LoadKeyBundle accepts
+// other parameters up to bundleMaxScryptMemory bytes of memory, 128*N*r.
+const (
+	bundleKDF             = "scrypt"
+	bundleScryptN         = 1 << 15
+	bundleScryptR         = 8
+	bundleScryptP         = 1
+	bundleMaxScryptMemory = 1 << 28
+	bundleMaxScryptP      = 16
+	bundleSaltSize        = 16
+)
+
+// scryptParams holds the cost parameters N, r and p of scrypt.
+type scryptParams struct {
+	N, r, p int
+}
+
+// SaveKeyBundle writes priv to w as a single key bundle of two PEM blocks.
+// The first one is the public key in the clear, as written by
+// EncodePublicKeyPEM, so that the group parameters and the public value can
+// be read without the passphrase. The second one holds the secret exponent
+// x encrypted with AES-256-GCM under a key derived from passphrase with
+// scrypt. Its headers record the KDF, the scrypt parameters N, r and p and
+// the salt, and the public key is authenticated along with x.
+func SaveKeyBundle(w io.Writer, priv *PrivateKey, passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return errors.New("elgamal: empty key bundle passphrase")
+	}
+	der, err := MarshalPublicKey(&priv.PublicKey)
+	if err != nil {
+		return err
+	}
+	if priv.X == nil || priv.X.Sign() <= 0 || priv.X.Cmp(priv.Order()) >= 0 {
+		return ErrInvalidPrivateKey
+	}
+
+	salt := make([]byte, bundleSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	params := scryptParams{N: bundleScryptN, r: bundleScryptR, p: bundleScryptP}
+	aead, err := newBundleAEAD(passphrase, salt, params)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	// x is padded to the size of q, so that its length is not revealed
+	x := priv.X.FillBytes(make([]byte, (priv.Order().BitLen()+7)/8))
+	sealed := aead.Seal(nonce, nonce, x, der)
+
+	if err := pem.Encode(w, &pem.Block{Type: PublicKeyPEMType, Bytes: der}); err != nil {
+		return err
+	}
+	return pem.Encode(w, &pem.Block{
+		Type: EncryptedPrivateKeyPEMType,
+		Headers: map[string]string{
+			"KDF":  bundleKDF,
+			"N":    strconv.Itoa(params.N),
+			"R":    strconv.Itoa(params.r),
+			"P":    strconv.Itoa(params.p),
+			"Salt": hex.EncodeToString(salt),
+		},
+		Bytes: sealed,
+	})
+}
+
+// LoadKeyBundle reads a key bundle written by SaveKeyBundle and decrypts
+// its private key with passphrase. It returns ErrBundleAuth if the
+// passphrase is wrong or if either block of the bundle has been modified.
+func LoadKeyBundle(r io.Reader, passphrase []byte) (*PrivateKey, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pubBlock, rest := pem.Decode(data)
+	if pubBlock == nil || pubBlock.Type != PublicKeyPEMType {
+		return nil, errors.New("elgamal: key bundle lacks the public key")
+	}
+	privBlock, _ := pem.Decode(rest)
+	if privBlock == nil || privBlock.Type != EncryptedPrivateKeyPEMType {
+		return nil, errors.New("elgamal: key bundle lacks the private key")
+	}
+	pub, err := ParsePublicKey(pubBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if privBlock.Headers["KDF"] != bundleKDF {
+		return nil, errors.New("elgamal: unsupported key bundle KDF")
+	}
+	params, err := parseScryptParams(privBlock.Headers)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(privBlock.Headers["Salt"])
+	if err != nil || len(salt) == 0 {
+		return nil, errors.New("elgamal: invalid key bundle salt")
+	}
+
+	aead, err := newBundleAEAD(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(privBlock.Bytes) < aead.NonceSize() {
+		return nil, errBinaryTruncated
+	}
+	nonce, sealed := privBlock.Bytes[:aead.NonceSize()], privBlock.Bytes[aead.NonceSize():]
+	x, err := aead.Open(nil, nonce, sealed, pubBlock.Bytes)
+	if err != nil {
+		return nil, ErrBundleAuth
+	}
+
+	priv := &PrivateKey{PublicKey: *pub, X: new(big.Int).SetBytes(x)}
+	// y = g^x mod p
+	if priv.expX(priv.G).Cmp(priv.Y) != 0 {
+		return nil, errors.New("elgamal: private key does not match public key")
+	}
+	return priv, nil
+}
+
+// parseScryptParams reads the scrypt parameters from the headers of a key
+// bundle. N must be a power of two greater than 1, and the parameters are
+// bounded, so that a crafted bundle cannot exhaust memory or time.
+func parseScryptParams(headers map[string]string) (scryptParams, error) {
+	var params scryptParams
+	var err error
+	if params.N, err = strconv.Atoi(headers["N"]); err != nil {
+		return params, errBundleScryptParams
+	}
+	if params.r, err = strconv.Atoi(headers["R"]); err != nil {
+		return params, errBundleScryptParams
+	}
+	if params.p, err = strconv.Atoi(headers["P"]); err != nil {
+		return params, errBundleScryptParams
+	}
+	if params.N <= 1 || params.N&(params.N-1) != 0 || params.r < 1 ||
+		params.p < 1 || params.p > bundleMaxScryptP ||
+		params.N > bundleMaxScryptMemory/128/params.r {
+		return params, errBundleScryptParams
+	}
+	return params, nil
+}
+
+// newBundleAEAD returns AES-256-GCM under the key derived from passphrase
+// and salt with scrypt and the given parameters.
+func newBundleAEAD(passphrase, salt []byte, params scryptParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, params.N, params.r, params.p, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
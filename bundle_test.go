@@ -0,0 +1,79 @@
+package elgamal
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyBundle(t *testing.T) {
+	priv := newTestKey(t)
+	pass := []byte("correct horse")
+	var buf bytes.Buffer
+	if err := SaveKeyBundle(&buf, priv, pass); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadKeyBundle(bytes.NewReader(buf.Bytes()), pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) {
+		t.Error("loaded key differs from the saved one")
+	}
+	if _, err := LoadKeyBundle(bytes.NewReader(buf.Bytes()), []byte("wrong")); !errors.Is(err, ErrBundleAuth) {
+		t.Errorf("wrong passphrase: got %v, want ErrBundleAuth", err)
+	}
+
+	// the public block can be read without the passphrase, but it is
+	// authenticated along with the private key
+	pub, err := DecodePublicKeyPEM(buf.Bytes())
+	if err != nil || !pub.Equal(&priv.PublicKey) {
+		t.Fatalf("DecodePublicKeyPEM: %v", err)
+	}
+	other, err := KeyFromExponent(testParams(), one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPEM, err := EncodePublicKeyPEM(&other.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	i := strings.Index(s, "-----BEGIN "+EncryptedPrivateKeyPEMType)
+	if _, err := LoadKeyBundle(strings.NewReader(string(otherPEM)+s[i:]), pass); !errors.Is(err, ErrBundleAuth) {
+		t.Errorf("swapped public key: got %v, want ErrBundleAuth", err)
+	}
+
+	if err := SaveKeyBundle(&buf, priv, nil); err == nil {
+		t.Error("SaveKeyBundle accepted an empty passphrase")
+	}
+}
+
+func TestKeyBundleScryptParams(t *testing.T) {
+	priv := newTestKey(t)
+	var buf bytes.Buffer
+	if err := SaveKeyBundle(&buf, priv, []byte("pass")); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range []string{"KDF: scrypt\n", "N: 32768\n", "R: 8\n", "P: 1\n"} {
+		if !strings.Contains(buf.String(), header) {
+			t.Fatalf("bundle lacks the header %q", header)
+		}
+	}
+
+	for _, tt := range []struct{ old, new string }{
+		{"N: 32768", "N: 1048576"}, // 1 GiB of memory
+		{"N: 32768", "N: 30000"},   // not a power of two
+		{"N: 32768", "N: 1"},
+		{"R: 8", "R: 0"},
+		{"P: 1", "P: 17"},
+		{"P: 1", "P: x"},
+	} {
+		s := strings.Replace(buf.String(), tt.old, tt.new, 1)
+		if _, err := LoadKeyBundle(strings.NewReader(s), []byte("pass")); err != errBundleScryptParams {
+			t.Errorf("%s: got %v, want errBundleScryptParams", tt.new, err)
+		}
+	}
+}
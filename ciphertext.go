@@ -0,0 +1,269 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// Ciphertext represents an Elgamal cipher as a pair of integers.
+type Ciphertext struct {
+	C1, C2 *big.Int
+}
+
+// joinCipher encodes the cipher (c1, c2) as a single byte array. Both
+// parts are written as a 4-byte big-endian length followed by its bytes.
+func joinCipher(c1, c2 []byte) []byte {
+	buf := make([]byte, 0, 8+len(c1)+len(c2))
+	buf = appendBytes(buf, c1)
+	return appendBytes(buf, c2)
+}
+
+// splitCipher decodes a cipher encoded by joinCipher into (c1, c2).
+func splitCipher(msg []byte) ([]byte, []byte, error) {
+	c1, rest, err := readBytes(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	c2, rest, err := readBytes(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) > 0 {
+		return nil, nil, errors.New("elgamal: trailing data after cipher")
+	}
+	return c1, c2, nil
+}
+
+// EncryptToBytes encrypts a plain text like Encrypt, but it returns both
+// parts of the cipher as a single byte array. Each part is written as a
+// 4-byte big-endian length followed by its bytes: len(c1) || c1 || len(c2) || c2.
+func (pub *PublicKey) EncryptToBytes(message []byte) ([]byte, error) {
+	c1, c2, err := pub.Encrypt(message)
+	if err != nil {
+		return nil, err
+	}
+	return joinCipher(c1, c2), nil
+}
+
+// DecryptFromBytes decrypts a cipher produced by EncryptToBytes.
+func (priv *PrivateKey) DecryptFromBytes(ciphertext []byte) ([]byte, error) {
+	c1, c2, err := splitCipher(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Decrypt(c1, c2)
+}
+
+// Marshal encodes the cipher like EncryptToBytes, as
+// len(c1) || c1 || len(c2) || c2.
+func (ct *Ciphertext) Marshal() ([]byte, error) {
+	if ct.C1 == nil || ct.C2 == nil {
+		return nil, errors.New("elgamal: missing cipher parts")
+	}
+	return joinCipher(ct.C1.Bytes(), ct.C2.Bytes()), nil
+}
+
+// Unmarshal decodes a cipher encoded by Marshal into ct.
+func (ct *Ciphertext) Unmarshal(data []byte) error {
+	c1, c2, err := splitCipher(data)
+	if err != nil {
+		return err
+	}
+	ct.C1 = new(big.Int).SetBytes(c1)
+	ct.C2 = new(big.Int).SetBytes(c2)
+	return nil
+}
+
+// MarshalFixed encodes the cipher as c1 || c2, with both parts left-padded
+// with zero bytes to byteLen bytes, which is usually the Size of the key.
+// Every cipher of a key is thus encoded in exactly 2*byteLen bytes. It
+// returns ErrCipherLarge if a part does not fit into byteLen bytes.
+func (ct *Ciphertext) MarshalFixed(byteLen int) ([]byte, error) {
+	if ct.C1 == nil || ct.C2 == nil {
+		return nil, errors.New("elgamal: missing cipher parts")
+	}
+	if ct.C1.Sign() < 0 || ct.C2.Sign() < 0 ||
+		(ct.C1.BitLen()+7)/8 > byteLen || (ct.C2.BitLen()+7)/8 > byteLen {
+		return nil, ErrCipherLarge
+	}
+
+	buf := make([]byte, 2*byteLen)
+	ct.C1.FillBytes(buf[:byteLen])
+	ct.C2.FillBytes(buf[byteLen:])
+	return buf, nil
+}
+
+// UnmarshalFixed decodes a cipher encoded by MarshalFixed into ct. The
+// width of each part is taken as half the length of data.
+func (ct *Ciphertext) UnmarshalFixed(data []byte) error {
+	if len(data)%2 != 0 {
+		return errors.New("elgamal: fixed-width cipher has odd length")
+	}
+	byteLen := len(data) / 2
+	ct.C1 = new(big.Int).SetBytes(data[:byteLen])
+	ct.C2 = new(big.Int).SetBytes(data[byteLen:])
+	return nil
+}
+
+// Equal reports whether ct and other hold the same cipher. Both parts are
+// compared in constant time over encodings of the fixed width byteLen,
+// usually the CiphertextLen of the key, so the time taken depends only on
+// byteLen and reveals neither the values nor how long a common prefix is.
+// This matters when one of the ciphers is secret and the other is chosen
+// by an attacker, e.g. when checking a cipher received from a peer against
+// an expected one; for public ciphers, comparing their encodings is fine.
+// A cipher with a part that does not fit into byteLen bytes is never equal.
+func (ct *Ciphertext) Equal(other *Ciphertext, byteLen int) bool {
+	if other == nil || byteLen <= 0 {
+		return false
+	}
+	a, err := ct.MarshalFixed(byteLen)
+	if err != nil {
+		return false
+	}
+	b, err := other.MarshalFixed(byteLen)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// EncryptFixed encrypts a plain text like Encrypt, but it left-pads both
+// parts of the cipher with zero bytes to exactly CiphertextLen bytes, so that
+// the cipher length does not depend on the random k. Decrypt accepts the
+// padded parts as is.
+func (pub *PublicKey) EncryptFixed(message []byte) ([]byte, []byte, error) {
+	c1, c2, err := pub.Encrypt(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	n := pub.CiphertextLen()
+	return new(big.Int).SetBytes(c1).FillBytes(make([]byte, n)),
+		new(big.Int).SetBytes(c2).FillBytes(make([]byte, n)), nil
+}
+
+// MarshalCompact encodes the cipher as len(c1) || c1 || len(c2) || c2
+// like Marshal, but both lengths are written as unsigned varints instead of
+// 4-byte integers, and both parts are stripped of leading zero bytes. For
+// keys of up to 1016 bits, the length headers take a single byte each.
+func (ct *Ciphertext) MarshalCompact() ([]byte, error) {
+	if ct.C1 == nil || ct.C2 == nil {
+		return nil, errors.New("elgamal: missing cipher parts")
+	}
+	c1, c2 := ct.C1.Bytes(), ct.C2.Bytes()
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+len(c1)+len(c2))
+	buf = binary.AppendUvarint(buf, uint64(len(c1)))
+	buf = append(buf, c1...)
+	buf = binary.AppendUvarint(buf, uint64(len(c2)))
+	return append(buf, c2...), nil
+}
+
+// UnmarshalCompact decodes a cipher encoded by MarshalCompact into ct.
+// It returns an error if a declared length exceeds the available bytes.
+func (ct *Ciphertext) UnmarshalCompact(data []byte) error {
+	var parts [2]*big.Int
+	for i := range parts {
+		n, k := binary.Uvarint(data)
+		if k <= 0 || n > uint64(len(data)-k) {
+			return errBinaryTruncated
+		}
+		data = data[k:]
+		parts[i] = new(big.Int).SetBytes(data[:n])
+		data = data[n:]
+	}
+	if len(data) > 0 {
+		return errors.New("elgamal: trailing data after cipher")
+	}
+	ct.C1, ct.C2 = parts[0], parts[1]
+	return nil
+}
+
+// EncryptCT encrypts a plain text like Encrypt, but it returns the cipher
+// as a Ciphertext.
+func (pub *PublicKey) EncryptCT(message []byte) (*Ciphertext, error) {
+	c1, c2, err := pub.Encrypt(message)
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{
+		C1: new(big.Int).SetBytes(c1),
+		C2: new(big.Int).SetBytes(c2),
+	}, nil
+}
+
+// EncryptBigInt encrypts the integer m directly, without converting it to
+// bytes first, and returns the cipher as a Ciphertext. It returns
+// ErrMessageLarge unless 0 <= m < P, and ErrMessageZero like Encrypt if
+// m is zero. DecryptBig recovers m exactly.
+func (pub *PublicKey) EncryptBigInt(m *big.Int) (*Ciphertext, error) {
+	if m.Sign() < 0 || m.Cmp(pub.P) >= 0 { //  0 <= m < P
+		return nil, ErrMessageLarge
+	}
+
+	// choose random integer k from {1...(q-1)}
+	k, err := randExponent(rand.Reader, pub.Order())
+	if err != nil {
+		return nil, err
+	}
+	c1, c2, err := pub.encrypt(m, k)
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{
+		C1: new(big.Int).SetBytes(c1),
+		C2: new(big.Int).SetBytes(c2),
+	}, nil
+}
+
+// DecryptCT decrypts a cipher produced by EncryptCT.
+func (priv *PrivateKey) DecryptCT(ct *Ciphertext) ([]byte, error) {
+	if ct == nil || ct.C1 == nil || ct.C2 == nil {
+		return nil, errors.New("elgamal: missing cipher parts")
+	}
+	return priv.Decrypt(ct.C1.Bytes(), ct.C2.Bytes())
+}
+
+// HomomorphicEncMultipleCT is like HomomorphicEncMultiple, but it takes
+// and returns ciphers as Ciphertext.
+func (pub *PublicKey) HomomorphicEncMultipleCT(ciphertexts []*Ciphertext) (*Ciphertext, error) {
+	// C1, C2, _ := pub.Encrypt(one.Bytes())
+	C1 := new(big.Int).Set(one) // since, c = 1^e mod n is equal to 1
+	C2 := new(big.Int).Set(one)
+
+	for _, ct := range ciphertexts {
+		if ct == nil || ct.C1 == nil || ct.C2 == nil {
+			return nil, errors.New("elgamal: missing cipher parts")
+		}
+		if ct.C1.Sign() < 0 || ct.C2.Sign() < 0 ||
+			ct.C1.Cmp(pub.P) >= 0 || ct.C2.Cmp(pub.P) >= 0 { //  0 <= (c1, c2) < P
+			return nil, ErrCipherLarge
+		}
+
+		// C1 = (c1)_1 * (c1)_2 * (c1)_3 ...(c1)_n mod p
+		mulMod(C1, C1, ct.C1, pub.P)
+
+		// C2 = (c2)_1 * (c2)_2 * (c2)_3 ...(c2)_n mod p
+		mulMod(C2, C2, ct.C2, pub.P)
+	}
+	return &Ciphertext{C1: C1, C2: C2}, nil
+}
+
+// EncryptToMany encrypts the same plain text for each of the passed public
+// keys. Every recipient gets an independently randomized cipher, which
+// decrypts only with its own private key; the i-th cipher belongs to the
+// i-th public key. It returns ErrMessageLarge if the message does not fit
+// any of the keys.
+func EncryptToMany(pubs []*PublicKey, message []byte) ([]Ciphertext, error) {
+	cts := make([]Ciphertext, len(pubs))
+	for i, pub := range pubs {
+		ct, err := pub.EncryptCT(message)
+		if err != nil {
+			return nil, err
+		}
+		cts[i] = *ct
+	}
+	return cts, nil
+}
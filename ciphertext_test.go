@@ -0,0 +1,287 @@
+package elgamal
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestEncryptToBytes(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("single slice")
+	ct, err := priv.EncryptToBytes(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.DecryptFromBytes(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m, msg) {
+		t.Errorf("got %q, want %q", m, msg)
+	}
+
+	// leading zero bytes of either part survive the encoding
+	c1, c2, err := splitCipher(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z1, z2 := append([]byte{0, 0}, c1...), append([]byte{0}, c2...)
+	g1, g2, err := splitCipher(joinCipher(z1, z2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(g1, z1) || !bytes.Equal(g2, z2) {
+		t.Error("zero-prefixed parts changed in the round trip")
+	}
+	if m, err := priv.DecryptFromBytes(joinCipher(z1, z2)); err != nil || !bytes.Equal(m, msg) {
+		t.Errorf("zero-prefixed cipher decrypted to %q, %v", m, err)
+	}
+
+	if _, err := priv.DecryptFromBytes(ct[:len(ct)-1]); err == nil {
+		t.Error("accepted a truncated cipher")
+	}
+	if _, err := priv.DecryptFromBytes(append(ct, 0)); err == nil {
+		t.Error("accepted trailing data")
+	}
+}
+
+func TestCiphertextStruct(t *testing.T) {
+	priv := newTestKey(t)
+	var cts []*Ciphertext
+	for _, m := range []int64{2, 3, 7} {
+		ct, err := priv.EncryptBigInt(big.NewInt(m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts = append(cts, ct)
+	}
+
+	data, err := cts[0].Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Ciphertext
+	if err := got.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if got.C1.Cmp(cts[0].C1) != 0 || got.C2.Cmp(cts[0].C2) != 0 {
+		t.Error("cipher changed in the round trip")
+	}
+
+	product, err := priv.HomomorphicEncMultipleCT(cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.DecryptCT(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).SetBytes(m).Int64() != 42 {
+		t.Errorf("product decrypted to %x, want 42", m)
+	}
+}
+
+func TestEncryptToMany(t *testing.T) {
+	var privs []*PrivateKey
+	var pubs []*PublicKey
+	for i := 0; i < 3; i++ {
+		priv := newTestKey(t)
+		privs = append(privs, priv)
+		pubs = append(pubs, &priv.PublicKey)
+	}
+	msg := []byte("broadcast")
+	cts, err := EncryptToMany(pubs, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cts) != len(pubs) {
+		t.Fatalf("got %d ciphers, want %d", len(cts), len(pubs))
+	}
+	for i := range cts {
+		m, err := privs[i].DecryptCT(&cts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Errorf("recipient %d decrypted %q", i, m)
+		}
+	}
+	if cts[0].C1.Cmp(cts[1].C1) == 0 {
+		t.Error("recipients share the random integer k")
+	}
+}
+
+func TestMarshalFixed(t *testing.T) {
+	priv := newTestKey(t)
+	// c1 has many leading zero bytes, c2 has none
+	ct := &Ciphertext{C1: big.NewInt(5), C2: new(big.Int).Sub(priv.P, one)}
+	data, err := ct.MarshalFixed(priv.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2*priv.Size() {
+		t.Fatalf("got %d bytes, want %d", len(data), 2*priv.Size())
+	}
+	var got Ciphertext
+	if err := got.UnmarshalFixed(data); err != nil {
+		t.Fatal(err)
+	}
+	if got.C1.Cmp(ct.C1) != 0 || got.C2.Cmp(ct.C2) != 0 {
+		t.Error("cipher changed in the round trip")
+	}
+
+	if _, err := ct.MarshalFixed(2); err != ErrCipherLarge {
+		t.Errorf("got %v, want ErrCipherLarge", err)
+	}
+	if err := got.UnmarshalFixed([]byte{1, 2, 3}); err == nil {
+		t.Error("accepted a cipher of odd length")
+	}
+}
+
+func TestEncryptBigInt(t *testing.T) {
+	priv := newTestKey(t)
+	// small values have leading zero bytes when encoded to the key size
+	for _, m := range []*big.Int{big.NewInt(1), big.NewInt(0xff00), new(big.Int).Sub(priv.P, one)} {
+		ct, err := priv.EncryptBigInt(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := priv.DecryptBig(ct.C1.Bytes(), ct.C2.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(m) != 0 {
+			t.Errorf("got %v, want %v", got, m)
+		}
+	}
+	for _, m := range []*big.Int{big.NewInt(-1), priv.P} {
+		if _, err := priv.EncryptBigInt(m); err != ErrMessageLarge {
+			t.Errorf("EncryptBigInt(%v): got %v, want ErrMessageLarge", m, err)
+		}
+	}
+	if _, err := priv.EncryptBigInt(new(big.Int)); err != ErrMessageZero {
+		t.Errorf("EncryptBigInt(0): got %v, want ErrMessageZero", err)
+	}
+}
+
+func TestMarshalCompact(t *testing.T) {
+	priv := newTestKey(t)
+	ct, err := priv.EncryptCT([]byte("compact"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ct.MarshalCompact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := ct.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) >= len(full) {
+		t.Errorf("compact encoding takes %d bytes, Marshal %d", len(data), len(full))
+	}
+
+	var got Ciphertext
+	if err := got.UnmarshalCompact(data); err != nil {
+		t.Fatal(err)
+	}
+	if got.C1.Cmp(ct.C1) != 0 || got.C2.Cmp(ct.C2) != 0 {
+		t.Error("cipher changed in the round trip")
+	}
+	for i := 0; i < len(data); i++ {
+		if err := new(Ciphertext).UnmarshalCompact(data[:i]); err == nil {
+			t.Fatalf("accepted a cipher truncated to %d bytes", i)
+		}
+	}
+	if err := new(Ciphertext).UnmarshalCompact(append(data, 0)); err == nil {
+		t.Error("accepted trailing data")
+	}
+	huge := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+	if err := new(Ciphertext).UnmarshalCompact(huge); err != errBinaryTruncated {
+		t.Errorf("length of 2^64-1: got %v, want errBinaryTruncated", err)
+	}
+}
+
+func BenchmarkMarshalCompact(b *testing.B) {
+	priv := newTestKey(b)
+	ct, err := priv.EncryptCT([]byte("compact"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var data []byte
+	for i := 0; i < b.N; i++ {
+		if data, err = ct.MarshalCompact(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	full, err := ct.Marshal()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportMetric(float64(len(data)), "compact-bytes")
+	b.ReportMetric(float64(len(full)), "marshal-bytes")
+}
+
+func TestEncryptFixed(t *testing.T) {
+	priv := newTestKey(t)
+	n := priv.CiphertextLen()
+	if n != priv.Size() {
+		t.Errorf("CiphertextLen = %d, want Size = %d", n, priv.Size())
+	}
+	// with 64 ciphers, some part has a leading zero byte with high probability
+	for i := 0; i < 64; i++ {
+		c1, c2, err := priv.EncryptFixed([]byte("fixed"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c1) != n || len(c2) != n {
+			t.Fatalf("got parts of %d and %d bytes, want %d", len(c1), len(c2), n)
+		}
+		m, err := priv.Decrypt(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, []byte("fixed")) {
+			t.Fatalf("got %q, want %q", m, "fixed")
+		}
+	}
+}
+
+func TestCiphertextEqual(t *testing.T) {
+	priv := newTestKey(t)
+	n := priv.CiphertextLen()
+	a, err := priv.EncryptCT([]byte("equal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := priv.EncryptCT([]byte("equal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Ciphertext{C1: new(big.Int).Set(a.C1), C2: new(big.Int).Set(a.C2)}
+	if !a.Equal(c, n) || !c.Equal(a, n) {
+		t.Error("copies of a cipher differ")
+	}
+	if a.Equal(b, n) {
+		t.Error("independent ciphers are equal")
+	}
+	if a.Equal(nil, n) || a.Equal(&Ciphertext{}, n) || (&Ciphertext{}).Equal(a, n) {
+		t.Error("a cipher without parts is equal")
+	}
+	if a.Equal(c, n/2) || a.Equal(c, 0) || a.Equal(c, -1) {
+		t.Error("ciphers wider than byteLen are equal")
+	}
+
+	small := &Ciphertext{C1: big.NewInt(1), C2: big.NewInt(2)}
+	if a.Equal(small, n) || small.Equal(a, n) {
+		t.Error("different ciphers are equal")
+	}
+	if !small.Equal(&Ciphertext{C1: big.NewInt(1), C2: big.NewInt(2)}, n) {
+		t.Error("equal small ciphers differ")
+	}
+	if small.Equal(&Ciphertext{C1: big.NewInt(2), C2: big.NewInt(1)}, n) {
+		t.Error("ciphers with swapped parts are equal")
+	}
+}
@@ -0,0 +1,64 @@
+package elgamal
+
+import (
+	"crypto"
+	"io"
+)
+
+// The key types implement the methods that the crypto package documents
+// for all public and private keys of the standard library.
+var (
+	_ interface {
+		Equal(crypto.PublicKey) bool
+	} = (*PublicKey)(nil)
+	_ interface {
+		Public() crypto.PublicKey
+		Equal(crypto.PrivateKey) bool
+	} = (*PrivateKey)(nil)
+	_ crypto.Decrypter = decrypter{}
+	_ Decryptor        = (*PrivateKey)(nil)
+)
+
+// Decryptor is implemented by keys that decrypt Elgamal ciphers, such as
+// PrivateKey. Keys whose secret exponent is held by an HSM or KMS and is not
+// available in memory may implement it by delegating the decryption, so
+// that applications can handle both kinds of keys alike.
+type Decryptor interface {
+	// DecryptCipher decrypts the cipher (c1, c2) like PrivateKey.Decrypt.
+	DecryptCipher(c1, c2 []byte) ([]byte, error)
+}
+
+// DecryptCipher implements the Decryptor interface by calling Decrypt.
+func (priv *PrivateKey) DecryptCipher(c1, c2 []byte) ([]byte, error) {
+	return priv.Decrypt(c1, c2)
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	return &priv.PublicKey
+}
+
+// CryptoDecrypter returns priv as a crypto.Decrypter. Its Decrypt method
+// expects msg to hold both parts of the cipher, each written as a 4-byte
+// big-endian length followed by its bytes: len(c1) || c1 || len(c2) || c2.
+// The rand and opts arguments are ignored.
+func (priv *PrivateKey) CryptoDecrypter() crypto.Decrypter {
+	return decrypter{priv}
+}
+
+// decrypter implements the crypto.Decrypter interface for a PrivateKey.
+type decrypter struct {
+	priv *PrivateKey
+}
+
+func (d decrypter) Public() crypto.PublicKey {
+	return d.priv.Public()
+}
+
+func (d decrypter) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	c1, c2, err := splitCipher(msg)
+	if err != nil {
+		return nil, err
+	}
+	return d.priv.Decrypt(c1, c2)
+}
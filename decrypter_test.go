@@ -0,0 +1,76 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCryptoDecrypter(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("decrypter")
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d crypto.Decrypter = priv.CryptoDecrypter()
+	if !priv.PublicKey.Equal(d.Public()) {
+		t.Error("Public returned another key")
+	}
+	// len(c1) || c1 || len(c2) || c2
+	m, err := d.Decrypt(rand.Reader, appendBytes(appendBytes(nil, c1), c2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m, msg) {
+		t.Errorf("got %q, want %q", m, msg)
+	}
+	if _, err := d.Decrypt(rand.Reader, appendBytes(nil, c1), nil); err == nil {
+		t.Error("accepted a message without c2")
+	}
+}
+
+func TestPublic(t *testing.T) {
+	priv := newTestKey(t)
+	pub, ok := priv.Public().(*PublicKey)
+	if !ok || pub != &priv.PublicKey {
+		t.Errorf("Public returned %T", priv.Public())
+	}
+}
+
+// remoteDecryptor stands in for a key held by an HSM or KMS, which only
+// exposes a decryption call and never the secret exponent.
+type remoteDecryptor struct {
+	decrypt func(c1, c2 []byte) ([]byte, error)
+	calls   int
+}
+
+func (r *remoteDecryptor) DecryptCipher(c1, c2 []byte) ([]byte, error) {
+	r.calls++
+	return r.decrypt(c1, c2)
+}
+
+func TestDecryptor(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("decryptor")
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := &remoteDecryptor{decrypt: priv.Decrypt}
+	for _, d := range []Decryptor{priv, remote} {
+		m, err := d.DecryptCipher(c1, c2)
+		if err != nil {
+			t.Fatalf("%T: %v", d, err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Errorf("%T: got %q, want %q", d, m, msg)
+		}
+	}
+	if remote.calls != 1 {
+		t.Errorf("remote decryptor called %d times, want 1", remote.calls)
+	}
+}
@@ -0,0 +1,167 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+var ErrInvalidDKGShare = errors.New("elgamal: DKG share does not match commitments")
+
+// DKGParticipant represents one of N participants of a distributed key
+// generation without a trusted dealer. Every participant deals a random
+// polynomial of degree T-1 in the manner of SplitPrivateKey, broadcasts the
+// Feldman commitments to its coefficients and privately sends the share
+// f_i(j) to every other participant j. The joint secret exponent x is the
+// sum of the constant terms of all polynomials and is never known to anyone,
+// while any T of the resulting key shares are able to decrypt jointly.
+type DKGParticipant struct {
+	params       *Parameters
+	index        int
+	t, n         int
+	coefficients []*big.Int         // a_0 ... a_(t-1) of the own polynomial
+	commitments  []*big.Int         // C_k = g^(a_k) mod p
+	shares       map[int]*big.Int   // f_j(index) received from participant j
+	received     map[int][]*big.Int // commitments received from participant j
+}
+
+// NewDKGParticipant starts the distributed key generation for the participant
+// with the given index in {1...n} of n participants and threshold t in the
+// group of params. It chooses the random polynomial of the participant.
+func NewDKGParticipant(params *Parameters, t, n, index int) (*DKGParticipant, error) {
+	if t < 1 || n < t {
+		return nil, errors.New("elgamal: invalid threshold parameters")
+	}
+	if index < 1 || index > n {
+		return nil, errors.New("elgamal: invalid participant index")
+	}
+	if big.NewInt(int64(n)).Cmp(params.Q) >= 0 {
+		return nil, errors.New("elgamal: too many shares for group order")
+	}
+
+	d := &DKGParticipant{
+		params:       params,
+		index:        index,
+		t:            t,
+		n:            n,
+		coefficients: make([]*big.Int, t),
+		commitments:  make([]*big.Int, t),
+		shares:       make(map[int]*big.Int, n),
+		received:     make(map[int][]*big.Int, n),
+	}
+	for k := 0; k < t; k++ {
+		a, err := rand.Int(rand.Reader, params.Q)
+		if err != nil {
+			return nil, err
+		}
+		d.coefficients[k] = a
+		// C_k = g^(a_k) mod p
+		d.commitments[k] = new(big.Int).Exp(params.G, a, params.P)
+	}
+
+	// keep the own share of the own polynomial
+	d.shares[index] = evalPolynomial(d.coefficients, big.NewInt(int64(index)), params.Q)
+	d.received[index] = d.commitments
+	return d, nil
+}
+
+// Index returns the index of the participant.
+func (d *DKGParticipant) Index() int {
+	return d.index
+}
+
+// Commitments returns the Feldman commitments C_k = g^(a_k) mod p to the
+// coefficients of the polynomial of the participant, which are broadcast
+// to all other participants.
+func (d *DKGParticipant) Commitments() []*big.Int {
+	return d.commitments
+}
+
+// Share returns the share f_i(j) mod q of the polynomial of the participant
+// for the participant with index j, which must be sent to it privately.
+func (d *DKGParticipant) Share(j int) (*big.Int, error) {
+	if j < 1 || j > d.n {
+		return nil, errors.New("elgamal: invalid participant index")
+	}
+	return evalPolynomial(d.coefficients, big.NewInt(int64(j)), d.params.Q), nil
+}
+
+// VerifyShare reports whether share is the evaluation at index of the
+// polynomial committed to by commitments, by checking the Feldman equation
+// g^share = prod(C_k^(index^k)) mod p.
+func VerifyShare(params *Parameters, index int, share *big.Int, commitments []*big.Int) bool {
+	if share == nil || share.Sign() < 0 || share.Cmp(params.Q) >= 0 || len(commitments) == 0 {
+		return false
+	}
+
+	// prod(C_k^(index^k)) mod p
+	z := big.NewInt(int64(index))
+	power := new(big.Int).Set(one) // index^k mod q
+	right := new(big.Int).Set(one)
+	for _, c := range commitments {
+		if c == nil || c.Sign() <= 0 || c.Cmp(params.P) >= 0 {
+			return false
+		}
+		mulMod(right, right, new(big.Int).Exp(c, power, params.P), params.P)
+		mulMod(power, power, z, params.Q)
+	}
+
+	// g^share mod p
+	left := new(big.Int).Exp(params.G, share, params.P)
+	return left.Cmp(right) == 0
+}
+
+// AddShare records the share and the broadcast commitments received from
+// the participant with index from. It returns ErrInvalidDKGShare if the share
+// does not match the commitments, which exposes from as a cheating participant.
+func (d *DKGParticipant) AddShare(from int, share *big.Int, commitments []*big.Int) error {
+	if from < 1 || from > d.n {
+		return errors.New("elgamal: invalid participant index")
+	}
+	if _, ok := d.shares[from]; ok {
+		return errors.New("elgamal: duplicate DKG share")
+	}
+	if len(commitments) != d.t {
+		return errors.New("elgamal: invalid number of DKG commitments")
+	}
+	if !VerifyShare(d.params, d.index, share, commitments) {
+		return ErrInvalidDKGShare
+	}
+
+	d.shares[from] = new(big.Int).Set(share)
+	d.received[from] = commitments
+	return nil
+}
+
+// KeyShare completes the distributed key generation once the shares of all
+// N participants have been added. The share of the joint secret exponent is
+// x_i = sum(f_j(i)) mod q and the joint public value is y = prod(C_j,0) mod p.
+// The returned key share works with PartialDecrypt and CombineShares.
+func (d *DKGParticipant) KeyShare() (*KeyShare, error) {
+	if len(d.shares) != d.n {
+		return nil, errors.New("elgamal: missing DKG shares")
+	}
+
+	// x_i = sum(f_j(i)) mod q
+	x := new(big.Int)
+	// y = prod(C_j,0) mod p
+	y := new(big.Int).Set(one)
+	for j := 1; j <= d.n; j++ {
+		x.Add(x, d.shares[j])
+		mulMod(y, y, d.received[j][0], d.params.P)
+	}
+	x.Mod(x, d.params.Q)
+
+	return &KeyShare{
+		PublicKey: &PublicKey{
+			G: d.params.G,
+			P: d.params.P,
+			Y: y,
+			Q: d.params.Q,
+		},
+		Index: d.index,
+		T:     d.t,
+		N:     d.n,
+		X:     x,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package elgamal
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestDKG(t *testing.T) {
+	params := testParams()
+	const threshold, n = 3, 4
+	parts := make([]*DKGParticipant, n)
+	for i := range parts {
+		d, err := NewDKGParticipant(params, threshold, n, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[i] = d
+	}
+	for _, from := range parts {
+		for _, to := range parts {
+			if from == to {
+				continue
+			}
+			share, err := from.Share(to.Index())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := to.AddShare(from.Index(), share, from.Commitments()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	shares := make([]*KeyShare, n)
+	for i, d := range parts {
+		ks, err := d.KeyShare()
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = ks
+	}
+	pub := shares[0].PublicKey
+	for _, ks := range shares[1:] {
+		if !pub.Equal(ks.PublicKey) {
+			t.Fatal("participants disagree on the joint public key")
+		}
+	}
+
+	msg := []byte("joint key")
+	c1, c2, err := pub.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var partials []*PartialDecryption
+	for _, ks := range shares[1:] {
+		pd, err := ks.PartialDecrypt(c1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials = append(partials, pd)
+	}
+	m, err := CombineShares(pub, partials, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m, msg) {
+		t.Errorf("got %q, want %q", m, msg)
+	}
+}
+
+func TestDKGRejectsInvalidShare(t *testing.T) {
+	params := testParams()
+	a, err := NewDKGParticipant(params, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewDKGParticipant(params, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, err := a.Share(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := new(big.Int).Add(share, one)
+	bad.Mod(bad, params.Q)
+	if err := b.AddShare(1, bad, a.Commitments()); err != ErrInvalidDKGShare {
+		t.Errorf("got %v, want ErrInvalidDKGShare", err)
+	}
+	if _, err := b.KeyShare(); err == nil {
+		t.Error("completed without all shares")
+	}
+}
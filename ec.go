@@ -0,0 +1,216 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+var ErrInvalidECPoint = errors.New("elgamal: invalid elliptic curve point")
+
+// ECPublicKey represents an Elgamal public key over an elliptic curve.
+// The public point is Y = x*G, where G is the base point of the curve.
+//
+// The point arithmetic uses the low-level methods of elliptic.Curve, such
+// as ScalarMult and Add, and elliptic.Marshal and Unmarshal. They are
+// deprecated (staticcheck SA1019) in favor of crypto/ecdh, which offers no
+// point addition, so they are kept deliberately. They are not guaranteed
+// to run in constant time for custom curves.
+//
+// Only the short Weierstrass curves of crypto/elliptic, such as P-256, are
+// supported. Curve25519 and other Montgomery or Edwards curves are out of
+// scope: crypto/ecdh exposes no point addition for them either.
+//
+// The point at infinity has no encoding in the form of elliptic.Marshal, so
+// it is neither accepted as a plain text by Encrypt nor returned by Decrypt.
+type ECPublicKey struct {
+	elliptic.Curve
+	X, Y *big.Int
+}
+
+// ECPrivateKey represents an Elgamal private key over an elliptic curve.
+type ECPrivateKey struct {
+	ECPublicKey
+	D *big.Int // secret scalar
+}
+
+// GenerateECKey generates elgamal private key over the given curve,
+// such as elliptic.P256().
+func GenerateECKey(curve elliptic.Curve) (*ECPrivateKey, error) {
+	d, x, y, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &ECPrivateKey{
+		ECPublicKey: ECPublicKey{
+			Curve: curve,
+			X:     x,
+			Y:     y,
+		},
+		D: new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// ecNeg returns the inverse -(x, y) = (x, p - y) of a curve point.
+// The point at infinity, encoded as (0, 0), is its own inverse.
+func ecNeg(curve elliptic.Curve, x, y *big.Int) (*big.Int, *big.Int) {
+	if y.Sign() == 0 {
+		return x, y
+	}
+	return x, new(big.Int).Sub(curve.Params().P, y)
+}
+
+// unmarshalPoints parses the passed points in the uncompressed form of
+// elliptic.Marshal. It returns ErrInvalidECPoint if any of them is not a
+// point on the curve.
+func unmarshalPoints(curve elliptic.Curve, points ...[]byte) ([]*big.Int, error) {
+	coordinates := make([]*big.Int, 0, 2*len(points))
+	for _, point := range points {
+		x, y := elliptic.Unmarshal(curve, point)
+		if x == nil {
+			return nil, ErrInvalidECPoint
+		}
+		coordinates = append(coordinates, x, y)
+	}
+	return coordinates, nil
+}
+
+// Encrypt encrypts the passed point M on the curve, given in the
+// uncompressed form of elliptic.Marshal. The cipher consists of the points
+// C1 = k*G and C2 = M + k*Y, for a random integer k from {1...(n-1)}.
+func (pub *ECPublicKey) Encrypt(point []byte) ([]byte, []byte, error) {
+	m, err := unmarshalPoints(pub.Curve, point)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pub.encrypt(m[0], m[1])
+}
+
+func (pub *ECPublicKey) encrypt(mx, my *big.Int) ([]byte, []byte, error) {
+	// choose random integer k from {1...(n-1)}
+	k, err := randExponent(rand.Reader, pub.Params().N)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// C1 = k*G
+	c1x, c1y := pub.ScalarBaseMult(k.Bytes())
+	// S = k*Y
+	sx, sy := pub.ScalarMult(pub.X, pub.Y, k.Bytes())
+	// C2 = M + S
+	c2x, c2y := pub.Add(mx, my, sx, sy)
+	return elliptic.Marshal(pub.Curve, c1x, c1y), elliptic.Marshal(pub.Curve, c2x, c2y), nil
+}
+
+// Decrypt decrypts the passed cipher and returns the point M = C2 - D*C1
+// in the uncompressed form of elliptic.Marshal. It returns
+// ErrInvalidECPoint if M is the point at infinity, which Encrypt never
+// encrypts.
+func (priv *ECPrivateKey) Decrypt(cipher1, cipher2 []byte) ([]byte, error) {
+	mx, my, err := priv.decrypt(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+	// the point at infinity is (0, 0)
+	if mx.Sign() == 0 && my.Sign() == 0 {
+		return nil, ErrInvalidECPoint
+	}
+	return elliptic.Marshal(priv.Curve, mx, my), nil
+}
+
+func (priv *ECPrivateKey) decrypt(cipher1, cipher2 []byte) (*big.Int, *big.Int, error) {
+	c, err := unmarshalPoints(priv.Curve, cipher1, cipher2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// S = D*C1
+	sx, sy := priv.ScalarMult(c[0], c[1], priv.D.Bytes())
+	// M = C2 - S
+	sx, sy = ecNeg(priv.Curve, sx, sy)
+	mx, my := priv.Add(c[2], c[3], sx, sy)
+	return mx, my, nil
+}
+
+// HomomorphicAdd performs homomorphic operation over two passed chiphers.
+// Elliptic curve Elgamal has additive homomorphic property, so resultant
+// cipher contains the sum of the two points.
+func (pub *ECPublicKey) HomomorphicAdd(c1, c2, c1dash, c2dash []byte) ([]byte, []byte, error) {
+	c, err := unmarshalPoints(pub.Curve, c1, c2, c1dash, c2dash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// C1 = c1 + c1dash
+	C1x, C1y := pub.Add(c[0], c[1], c[4], c[5])
+	// C2 = c2 + c2dash
+	C2x, C2y := pub.Add(c[2], c[3], c[6], c[7])
+	return elliptic.Marshal(pub.Curve, C1x, C1y), elliptic.Marshal(pub.Curve, C2x, C2y), nil
+}
+
+// EncryptInt encrypts the integer m, encoded as the point m*G, so that the
+// homomorphic sum of two such ciphers contains the sum of the original
+// numbers. The value of m must be small enough to brute-force the discrete
+// logarithm on decryption.
+func (pub *ECPublicKey) EncryptInt(m *big.Int) ([]byte, []byte, error) {
+	if m.Sign() < 0 {
+		return nil, nil, errors.New("elgamal: message must not be negative")
+	}
+
+	// M = m*G
+	mx, my := pub.ScalarBaseMult(m.Bytes())
+	return pub.encrypt(mx, my)
+}
+
+// DecryptInt decrypts a cipher produced by EncryptInt. It recovers the point
+// m*G and then solves the discrete logarithm for m in {0...MaxDecryptInt}.
+// It returns ErrDiscreteLogNotFound if m lies outside of this range.
+func (priv *ECPrivateKey) DecryptInt(cipher1, cipher2 []byte) (*big.Int, error) {
+	mx, my, err := priv.decrypt(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+	return ecDiscreteLog(priv.Curve, mx, my, MaxDecryptInt)
+}
+
+// ecDiscreteLog finds x in {0...max} such that x*G = H on the curve, with
+// the baby-step giant-step algorithm like DiscreteLog.
+func ecDiscreteLog(curve elliptic.Curve, hx, hy *big.Int, max int64) (*big.Int, error) {
+	if max < 0 {
+		return nil, ErrDiscreteLogNotFound
+	}
+
+	// m = ceil(sqrt(max + 1))
+	m := new(big.Int).Sqrt(big.NewInt(max))
+	m.Add(m, one)
+
+	// baby steps: table[j*G] = j for j in {0...(m-1)}
+	table := make(map[string]int64, m.Int64())
+	gx, gy := curve.Params().Gx, curve.Params().Gy
+	ex, ey := new(big.Int), new(big.Int)
+	for j := int64(0); j < m.Int64(); j++ {
+		key := string(elliptic.Marshal(curve, ex, ey))
+		if _, ok := table[key]; !ok {
+			table[key] = j
+		}
+		ex, ey = curve.Add(ex, ey, gx, gy)
+	}
+
+	// factor = -(m*G)
+	fx, fy := curve.ScalarBaseMult(m.Bytes())
+	fx, fy = ecNeg(curve, fx, fy)
+
+	// giant steps: gamma = H - i*m*G for i in {0...m}
+	for i := int64(0); i <= m.Int64(); i++ {
+		if j, ok := table[string(elliptic.Marshal(curve, hx, hy))]; ok {
+			x := i*m.Int64() + j
+			if x > max {
+				break
+			}
+			return big.NewInt(x), nil
+		}
+		hx, hy = curve.Add(hx, hy, fx, fy)
+	}
+	return nil, ErrDiscreteLogNotFound
+}
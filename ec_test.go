@@ -0,0 +1,103 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+func TestECP256(t *testing.T) {
+	curve := elliptic.P256()
+	priv, err := GenerateECKey(curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := func(k byte) []byte {
+		x, y := curve.ScalarBaseMult([]byte{k})
+		return elliptic.Marshal(curve, x, y)
+	}
+
+	c1, c2, err := priv.Encrypt(point(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.Decrypt(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m, point(7)) {
+		t.Error("decrypted point differs from 7*G")
+	}
+
+	d1, d2, err := priv.Encrypt(point(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, s2, err := priv.HomomorphicAdd(c1, c2, d1, d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = priv.Decrypt(s1, s2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m, point(16)) {
+		t.Error("homomorphic sum differs from 16*G")
+	}
+
+	if _, _, err := priv.Encrypt([]byte{4, 1, 2}); err != ErrInvalidECPoint {
+		t.Errorf("invalid point: got %v, want ErrInvalidECPoint", err)
+	}
+}
+
+func TestECPointAtInfinity(t *testing.T) {
+	curve := elliptic.P256()
+	priv, err := GenerateECKey(curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infinity := elliptic.Marshal(curve, new(big.Int), new(big.Int))
+	if _, _, err := priv.Encrypt(infinity); err != ErrInvalidECPoint {
+		t.Errorf("Encrypt of infinity: got %v, want ErrInvalidECPoint", err)
+	}
+
+	// C2 = D*C1 decrypts to the point at infinity
+	c1 := elliptic.Marshal(curve, curve.Params().Gx, curve.Params().Gy)
+	c2 := elliptic.Marshal(curve, priv.X, priv.Y)
+	if _, err := priv.Decrypt(c1, c2); err != ErrInvalidECPoint {
+		t.Errorf("Decrypt to infinity: got %v, want ErrInvalidECPoint", err)
+	}
+	// DecryptInt still decodes the point at infinity as the integer 0
+	if m, err := priv.DecryptInt(c1, c2); err != nil || m.Sign() != 0 {
+		t.Errorf("DecryptInt to infinity: got %v, %v, want 0", m, err)
+	}
+}
+
+func TestECP256Int(t *testing.T) {
+	priv, err := GenerateECKey(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []int64{0, 1, 5000} {
+		a1, a2, err := priv.EncryptInt(big.NewInt(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b1, b2, err := priv.EncryptInt(big.NewInt(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r1, r2, err := priv.HomomorphicAdd(a1, a2, b1, b2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := priv.DecryptInt(r1, r2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Int64() != v+3 {
+			t.Errorf("%d + 3 decrypted to %v", v, n)
+		}
+	}
+}
@@ -1,108 +1,576 @@
 package elgamal
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
 	"math/big"
-	"time"
-
-	mathrand "math/rand"
+	"sync"
+	"sync/atomic"
 )
 
 var zero = big.NewInt(0)
 var one = big.NewInt(1)
 var two = big.NewInt(2)
 
+// intPool holds scratch integers for the intermediate products of the
+// modular arithmetic in the hot paths, so that they are not allocated
+// anew on every call.
+var intPool = sync.Pool{
+	New: func() any { return new(big.Int) },
+}
+
+// mulMod sets z to x*y mod m and returns z. The unreduced product is
+// computed in a pooled scratch integer, so z may alias x or y.
+func mulMod(z, x, y, m *big.Int) *big.Int {
+	t := intPool.Get().(*big.Int)
+	t.Mul(x, y)
+	z.Mod(t, m)
+	intPool.Put(t)
+	return z
+}
+
 var ErrMessageLarge = errors.New("elgamal: message is larger than public key size")
 var ErrCipherLarge = errors.New("elgamal: cipher is larger than public key size")
+var ErrInvalidPrime = errors.New("elgamal: modulus is not a safe prime")
+var ErrInvalidGenerator = errors.New("elgamal: invalid generator")
+var ErrGeneratorSubgroup = errors.New("elgamal: generator not in subgroup")
+var ErrInvalidPublicValue = errors.New("elgamal: invalid public value")
+var ErrInsecureParameters = errors.New("elgamal: insecure key generation parameters")
+var ErrGenerationFailed = errors.New("elgamal: can't emit <p,q,g>")
+var ErrWeakKey = errors.New("elgamal: key is weaker than required")
+var ErrMessageZero = errors.New("elgamal: message is zero")
+var ErrInvalidCiphertext = errors.New("elgamal: invalid cipher")
+var ErrInvalidPrivateKey = errors.New("elgamal: invalid private key")
+
+// validationRounds is the number of Miller-Rabin tests performed
+// while validating the modulus P of a public key.
+const validationRounds = 20
+
+// minBitSize is the smallest bit size of P accepted by key generation.
+const minBitSize = 512
+
+// genAttemptsPerBit bounds the number of candidate primes q drawn while
+// searching for a safe prime of n bits to genAttemptsPerBit * n. A random
+// prime q yields a safe prime p = 2q + 1 about once in every n/3 tries,
+// so the bound is only reached if the source of randomness is broken.
+const genAttemptsPerBit = 64
+
+// primeAttemptsPerBit bounds the number of candidates drawn by randPrime
+// for a prime of n bits to primeAttemptsPerBit * n. A random odd number of
+// n bits is prime about once in every n/3 tries.
+const primeAttemptsPerBit = 16
+
+// genGeneratorAttempts bounds the number of candidate generators drawn for
+// a safe prime; each of them generates the subgroup with probability 1/2.
+const genGeneratorAttempts = 128
 
 // PublicKey represents a Elgamal public key.
 type PublicKey struct {
 	G, P, Y *big.Int
+	// Q is the prime order of the cyclic subgroup generated by G. For
+	// the safe-prime groups used by this package Q = (P-1)/2, and it is
+	// derived from P whenever Q is nil.
+	Q *big.Int
+
+	precomputed *precomputed // fixed-base tables built by Precompute
+	lazyQ       atomic.Value // *lazyOrder, set by Order if Q is nil
 }
 
 // PrivateKey represents Elgamal private key.
 type PrivateKey struct {
 	PublicKey
 	X *big.Int
+
+	// SecureMode selects a Montgomery ladder for the exponentiations with
+	// the secret exponent X during decryption, which performs the same
+	// sequence of operations for every X at the cost of speed.
+	SecureMode bool
 }
 
 // GenerateKey generates elgamal private key according
 // to given bit size and probability. Moreover, the given probability
 // value is used in choosing prime number P for performing n Miller-Rabin
-// tests with 1 - 1/(4^n) probability false rate.
+// tests with 1 - 1/(4^n) probability false rate. It returns
+// ErrInsecureParameters if probability is smaller than 1 or bit size
+// is smaller than 512. All randomness is drawn from crypto/rand and never
+// from the clock, so key generation behaves the same under js/wasm.
 func GenerateKey(bitsize, probability int) (*PrivateKey, error) {
+	return GenerateKeyContext(context.Background(), bitsize, probability)
+}
+
+// GenerateKeyContext is like GenerateKey, but the search for the safe prime
+// P is aborted as soon as the given context is canceled or its deadline is
+// exceeded. In that case it returns ctx.Err().
+func GenerateKeyContext(ctx context.Context, bitsize, probability int) (*PrivateKey, error) {
 	// p is prime number
 	// q is prime group order
 	// g is cyclic group generator Zp
-	p, q, g, err := GeneratePQZp(bitsize, probability)
+	p, q, g, err := genContext(ctx, rand.Reader, bitsize, probability)
+	if err != nil {
+		return nil, err
+	}
+	return newPrivateKey(rand.Reader, p, q, g)
+}
+
+// GenerateKeyFromReader is like GenerateKey, but all randomness, for the
+// prime search, the choice of the generator g and the secret exponent x
+// alike, is drawn from r instead of crypto/rand. Passing the same
+// deterministic reader always yields the same key, which is useful for
+// reproducible test vectors and for custom entropy sources.
+func GenerateKeyFromReader(r io.Reader, bitsize, probability int) (*PrivateKey, error) {
+	p, q, g, err := genContext(context.Background(), r, bitsize, probability)
 	if err != nil {
 		return nil, err
 	}
+	return newPrivateKey(r, p, q, g)
+}
+
+// GenerateKeyWithRand generates a key like GenerateKey, but it threads the
+// single reader r through the whole generation, as GenerateKeyFromReader
+// does: the prime search, the choice of the generator and the secret
+// exponent. A deterministic r yields reproducible keys.
+func GenerateKeyWithRand(r io.Reader, bitsize, probability int) (*PrivateKey, error) {
+	return GenerateKeyFromReader(r, bitsize, probability)
+}
+
+// GenerateKeyParallel is like GenerateKey, but it searches for the safe
+// prime P on the given number of goroutines. The first <p,q,g> found by
+// any worker is used and the remaining workers are canceled. A worker that
+// fails does not end the search; an error is returned only if all workers
+// fail, and it is the error of the last one.
+func GenerateKeyParallel(bitsize, probability, workers int) (*PrivateKey, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	readers := make([]io.Reader, workers)
+	for i := range readers {
+		readers[i] = rand.Reader
+	}
+	return generateKeyParallel(readers, bitsize, probability)
+}
+
+// generateKeyParallel runs one worker per reader in readers, each one
+// searching for <p,q,g> with its own reader.
+func generateKeyParallel(readers []io.Reader, bitsize, probability int) (*PrivateKey, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		p, q, g *big.Int
+		err     error
+	}
+	results := make(chan result, len(readers))
+	for _, random := range readers {
+		go func(random io.Reader) {
+			p, q, g, err := genContext(ctx, random, bitsize, probability)
+			results <- result{p, q, g, err}
+		}(random)
+	}
+
+	var err error
+	for range readers {
+		res := <-results
+		if res.err != nil {
+			err = res.err
+			continue
+		}
+		return newPrivateKey(rand.Reader, res.p, res.q, res.g)
+	}
+	return nil, err
+}
 
-	randSource := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+// newPrivateKey chooses the secret exponent x from random for the given
+// prime p, prime group order q and cyclic group generator g.
+func newPrivateKey(random io.Reader, p, q, g *big.Int) (*PrivateKey, error) {
 	// choose random integer x from {1...(q-1)}
-	priv := new(big.Int).Rand(randSource, new(big.Int).Sub(q, one))
-	// y = g^p mod p
-	y := new(big.Int).Exp(g, priv, p)
+	x, err := randExponent(random, q)
+	if err != nil {
+		return nil, err
+	}
+	// y = g^x mod p
+	y := new(big.Int).Exp(g, x, p)
 
 	return &PrivateKey{
 		PublicKey: PublicKey{
 			G: g, // cyclic group generator Zp
 			P: p, // prime number
-			Y: y, // y = g^p mod p
+			Y: y, // y = g^x mod p
+			Q: q, // prime group order
 		},
-		X: priv, // secret key x
+		X: x, // secret key x
 	}, nil
 }
 
+// lazyOrder holds the order q derived from P for a key without Q.
+type lazyOrder struct {
+	once sync.Once
+	q    *big.Int
+}
+
+// Order returns the prime order q of the cyclic subgroup generated by G.
+// If Q is not set, it is derived as q = (p-1)/2 on the first call and
+// cached, which is safe for concurrent use. The returned value must not be
+// modified, and P must not be changed after the first call.
+func (pub *PublicKey) Order() *big.Int {
+	if pub.Q != nil {
+		return pub.Q
+	}
+
+	lazy, _ := pub.lazyQ.Load().(*lazyOrder)
+	if lazy == nil {
+		// only the first of concurrent callers stores its lazyOrder
+		pub.lazyQ.CompareAndSwap(nil, &lazyOrder{})
+		lazy = pub.lazyQ.Load().(*lazyOrder)
+	}
+	lazy.once.Do(func() {
+		lazy.q = new(big.Int).Rsh(pub.P, 1)
+	})
+	return lazy.q
+}
+
+// randExponent chooses random integer from {1...(q-1)}.
+func randExponent(random io.Reader, q *big.Int) (*big.Int, error) {
+	k, err := rand.Int(random, new(big.Int).Sub(q, one))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, one), nil
+}
+
 func GeneratePQZp(bitsize, probability int) (p, q, g *big.Int, err error) {
 	return Gen(bitsize, probability)
 }
 
+// GeneratePQZpWithGenerator is like GeneratePQZp, but it uses preferG as the
+// generator if it lies in {2...(p-2)} and generates the subgroup of prime
+// order q (g^q mod p = 1) of the found prime p. Otherwise it falls back to
+// the randomly chosen generator. A small generator such as 2 eases interop
+// with systems that expect one, and it is valid for about half of all
+// safe primes.
+func GeneratePQZpWithGenerator(bitsize, probability int, preferG *big.Int) (p, q, g *big.Int, err error) {
+	p, q, g, err = Gen(bitsize, probability)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if preferG != nil && preferG.Cmp(one) > 0 && preferG.Cmp(new(big.Int).Sub(p, one)) < 0 &&
+		new(big.Int).Exp(preferG, q, p).Cmp(one) == 0 {
+		g = new(big.Int).Set(preferG)
+	}
+	return p, q, g, nil
+}
+
+// Validate checks that the public key parameters are well-formed. P must
+// be a safe prime of the form 2q + 1, G must lie in {2...(p-1)} and
+// generate the prime-order subgroup (g^q mod p = 1), and Y must lie
+// in {2...(p-1)}. It should be called on keys received from an untrusted source.
+// It does not judge the size of P, see ValidateMinBits.
+func (pub *PublicKey) Validate() error {
+	if pub.P == nil || pub.G == nil || pub.Y == nil {
+		return errors.New("elgamal: missing public key parameters")
+	}
+
+	// p = 2q + 1, where both p and q are primes
+	if !IsSafePrime(pub.P, validationRounds) {
+		return ErrInvalidPrime
+	}
+	q := pub.Order()
+	if new(big.Int).Rsh(pub.P, 1).Cmp(q) != 0 {
+		return ErrInvalidPrime
+	}
+
+	// 1 < g < p
+	if pub.G.Cmp(one) <= 0 || pub.G.Cmp(pub.P) >= 0 {
+		return ErrInvalidGenerator
+	}
+	// g^q mod p = 1
+	if new(big.Int).Exp(pub.G, q, pub.P).Cmp(one) != 0 {
+		return ErrGeneratorSubgroup
+	}
+
+	// 1 < y < p
+	if pub.Y.Cmp(one) <= 0 || pub.Y.Cmp(pub.P) >= 0 {
+		return ErrInvalidPublicValue
+	}
+	return nil
+}
+
+// ValidateMinBits is like Validate, but if all checks pass and P is shorter
+// than the given number of bits, e.g. 2048, it returns an error wrapping
+// ErrWeakKey. The key is well-formed and still usable then.
+func (pub *PublicKey) ValidateMinBits(bits int) error {
+	if err := pub.Validate(); err != nil {
+		return err
+	}
+	if n := pub.StrengthBits(); n < bits {
+		return fmt.Errorf("%w: %d-bit modulus, %d bits required", ErrWeakKey, n, bits)
+	}
+	return nil
+}
+
+// IsSafePrime reports whether p is a safe prime, i.e. p is probably prime
+// and so is q = (p-1)/2, each tested with the given number of Miller-Rabin
+// rounds as in ProbablyPrime. It should be called on moduli received from
+// an untrusted source before trusting their group.
+func IsSafePrime(p *big.Int, probability int) bool {
+	if p == nil || p.Cmp(two) <= 0 || !p.ProbablyPrime(probability) {
+		return false
+	}
+	// q = (p-1)/2
+	return new(big.Int).Rsh(p, 1).ProbablyPrime(probability)
+}
+
+// InSubgroup reports whether x lies in the subgroup of prime order q,
+// i.e. 1 <= x < p and x^q mod p = 1. It should be called on the c1 part of
+// ciphers received from untrusted parties before combining them with the
+// homomorphic operations, to prevent small-subgroup attacks.
+func (pub *PublicKey) InSubgroup(x *big.Int) bool {
+	if x == nil || x.Sign() <= 0 || x.Cmp(pub.P) >= 0 {
+		return false
+	}
+	return new(big.Int).Exp(x, pub.Order(), pub.P).Cmp(one) == 0
+}
+
 // Encrypt encrypts a plain text represented as a byte array. It returns
-// an error if plain text value is larger than modulus P of Public key.
+// an error if plain text value is not smaller than modulus P of Public key.
+// It returns ErrMessageZero if the message is empty or consists of zero bytes
+// only, since its cipher would reveal it; use EncryptPadded for such messages.
 func (pub *PublicKey) Encrypt(message []byte) ([]byte, []byte, error) {
-	// choose random integer k from {1...p}
-	k, err := rand.Int(rand.Reader, pub.P)
+	return pub.EncryptWithReader(rand.Reader, message)
+}
+
+// EncryptWithReader is like Encrypt, but the random integer k is drawn
+// from r instead of crypto/rand.
+func (pub *PublicKey) EncryptWithReader(r io.Reader, message []byte) ([]byte, []byte, error) {
+	m := new(big.Int).SetBytes(message)
+	if m.Cmp(pub.P) >= 0 { //  m < P
+		return nil, nil, ErrMessageLarge
+	}
+
+	// choose random integer k from {1...(q-1)}, so that
+	// c1 = g^k mod p is never equal to 1.
+	k, err := randExponent(r, pub.Order())
 	if err != nil {
 		return nil, nil, err
 	}
+	return pub.encrypt(m, k)
+}
 
+// EncryptWithK is like Encrypt, but it uses the given integer k from
+// {1...(q-1)} instead of a random one, so that the cipher is deterministic.
+// A party that retains k can later prove which plain text a cipher holds
+// by re-encrypting it, see ReEncryptCheck. Since anyone who knows k can
+// decrypt the cipher, k must be kept as secret as the plain text itself,
+// and it must never be reused for another message.
+func (pub *PublicKey) EncryptWithK(message []byte, k *big.Int) ([]byte, []byte, error) {
 	m := new(big.Int).SetBytes(message)
-	if m.Cmp(pub.P) == 1 { //  m < P
+	if m.Cmp(pub.P) >= 0 { //  m < P
 		return nil, nil, ErrMessageLarge
 	}
+	if k.Sign() <= 0 || k.Cmp(pub.Order()) >= 0 {
+		return nil, nil, errors.New("elgamal: k is out of range")
+	}
+	return pub.encrypt(m, k)
+}
+
+// EncryptWithAudit is like Encrypt, but it also returns the random integer
+// k used for the cipher, which satisfies c1 = g^k mod p. Since k cannot be
+// recovered from the cipher without solving a discrete logarithm, it is
+// returned for auditing, e.g. with ReEncryptCheck. Anyone who knows k can
+// decrypt the cipher, so k must be kept as secret as the plain text itself.
+func (pub *PublicKey) EncryptWithAudit(message []byte) (c1, c2 []byte, k *big.Int, err error) {
+	m := new(big.Int).SetBytes(message)
+	if m.Cmp(pub.P) >= 0 { //  m < P
+		return nil, nil, nil, ErrMessageLarge
+	}
+
+	// choose random integer k from {1...(q-1)}
+	k, err = randExponent(rand.Reader, pub.Order())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	c1, c2, err = pub.encrypt(m, k)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return c1, c2, k, nil
+}
+
+// ReEncryptCheck reports whether (c1, c2) is the encryption of message
+// with the ephemeral integer k, by re-encrypting message with EncryptWithK
+// and comparing the result. It requires only the public key.
+func (pub *PublicKey) ReEncryptCheck(c1, c2, message []byte, k *big.Int) bool {
+	d1, d2, err := pub.EncryptWithK(message, k)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(c1, d1) && bytes.Equal(c2, d2)
+}
+
+// EncryptReturningSecret is like Encrypt, but it also returns the shared
+// secret s = y^k mod p that masks the message, for protocols that derive
+// further keys from it. Anyone who knows s can decrypt the cipher, so it
+// must be handled as carefully as the plain text and never be sent along
+// with the cipher. The holder of the private key recomputes s with
+// SharedSecret.
+func (pub *PublicKey) EncryptReturningSecret(message []byte) (c1, c2, s []byte, err error) {
+	m := new(big.Int).SetBytes(message)
+	if m.Cmp(pub.P) >= 0 { //  m < P
+		return nil, nil, nil, ErrMessageLarge
+	}
+	if m.Sign() == 0 {
+		return nil, nil, nil, ErrMessageZero
+	}
 
+	// choose random integer k from {1...(q-1)}
+	k, err := randExponent(rand.Reader, pub.Order())
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	// c1 = g^k mod p
-	c1 := new(big.Int).Exp(pub.G, k, pub.P)
+	cipher1 := pub.expG(k)
 	// s = y^k mod p
-	s := new(big.Int).Exp(pub.Y, k, pub.P)
+	secret := pub.expY(k)
 	// c2 = m*s mod p
-	c2 := new(big.Int).Mod(
-		new(big.Int).Mul(m, s),
-		pub.P,
-	)
+	cipher2 := mulMod(m, m, secret, pub.P)
+	return cipher1.Bytes(), cipher2.Bytes(), secret.Bytes(), nil
+}
+
+// SharedSecret recomputes the shared secret s = c1^x mod p of a cipher,
+// as returned by EncryptReturningSecret.
+func (priv *PrivateKey) SharedSecret(cipher1 []byte) ([]byte, error) {
+	c1 := new(big.Int).SetBytes(cipher1)
+	if c1.Cmp(priv.P) >= 0 { //  c1 < P
+		return nil, ErrCipherLarge
+	}
+	if !coprime(c1, priv.P) {
+		return nil, ErrInvalidCiphertext
+	}
+	return priv.expX(c1).Bytes(), nil
+}
+
+// encrypt encrypts 0 < m < p with the integer k.
+func (pub *PublicKey) encrypt(m, k *big.Int) ([]byte, []byte, error) {
+	// m = 0 yields c2 = 0 for every k, which would reveal the message
+	if m.Sign() == 0 {
+		return nil, nil, ErrMessageZero
+	}
+
+	// c1 = g^k mod p
+	c1 := pub.expG(k)
+	// s = y^k mod p
+	s := pub.expY(k)
+	// c2 = m*s mod p
+	c2 := mulMod(s, m, s, pub.P)
 	return c1.Bytes(), c2.Bytes(), nil
 }
 
-// Decrypt decrypts the passed cipher text. It returns an
-// error if cipher text value is larger than modulus P of Public key.
+// EncryptBatch encrypts multiple plain texts under the public key, each
+// with its own fresh random k. It returns the ciphers in the same order
+// as the messages and stops at the first message that fails to encrypt.
+func (pub *PublicKey) EncryptBatch(messages [][]byte) ([][2][]byte, error) {
+	ciphertext := make([][2][]byte, len(messages))
+	for i := 0; i < len(messages); i++ {
+		c1, c2, err := pub.Encrypt(messages[i])
+		if err != nil {
+			return nil, err
+		}
+		ciphertext[i] = [2][]byte{c1, c2}
+	}
+	return ciphertext, nil
+}
+
+// Decrypt decrypts the passed cipher text. It returns an error if
+// either cipher text value is not smaller than modulus P of Public key.
+// It returns ErrInvalidCiphertext if c1 is not coprime to P, e.g. zero,
+// or if c2 is zero, and ErrInvalidPrivateKey if the secret exponent is
+// out of range.
 func (priv *PrivateKey) Decrypt(cipher1, cipher2 []byte) ([]byte, error) {
+	m, err := priv.DecryptBig(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+	return m.Bytes(), nil
+}
+
+// DecryptBig is like Decrypt, but it returns the recovered plain text as
+// an integer. Unlike the byte array returned by Decrypt, it lets callers
+// restore leading zero bytes of fixed-width plain texts themselves.
+func (priv *PrivateKey) DecryptBig(cipher1, cipher2 []byte) (*big.Int, error) {
+	c1, c2, err := priv.parseCipher(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+
+	// s(inv) = c^(-x) mod p
+	s := priv.expNegX(c1)
+
+	// m = s(inv) * c2 mod p
+	m := mulMod(s, s, c2, priv.P)
+	return m, nil
+}
+
+// parseCipher parses and checks both parts of a cipher before decryption.
+// It returns ErrCipherLarge if either part is not smaller than P, and
+// ErrInvalidCiphertext if c1 is not coprime to P or c2 is zero. A zero c2
+// decrypts to the message 0, which encrypt never produces. It returns
+// ErrInvalidPrivateKey if the secret exponent x does not lie in
+// {1...(p-2)}, as expNegX requires.
+func (priv *PrivateKey) parseCipher(cipher1, cipher2 []byte) (*big.Int, *big.Int, error) {
+	if priv.P == nil || priv.X == nil || priv.X.Sign() <= 0 ||
+		priv.X.Cmp(new(big.Int).Sub(priv.P, one)) >= 0 {
+		return nil, nil, ErrInvalidPrivateKey
+	}
+
 	c1 := new(big.Int).SetBytes(cipher1)
 	c2 := new(big.Int).SetBytes(cipher2)
-	if c1.Cmp(priv.P) == 1 && c2.Cmp(priv.P) == 1 { //  (c1, c2) < P
-		return nil, ErrCipherLarge
+	if c1.Cmp(priv.P) >= 0 || c2.Cmp(priv.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
 	}
+	if !coprime(c1, priv.P) || c2.Sign() == 0 {
+		return nil, nil, ErrInvalidCiphertext
+	}
+	return c1, c2, nil
+}
 
-	// s = c^x mod p
-	s := new(big.Int).Exp(c1, priv.X, priv.P)
-	// s = s(inv) = s^(-1) mod p
-	if s.ModInverse(s, priv.P) == nil {
-		return nil, errors.New("elgamal: invalid private key")
+// coprime reports whether gcd(c, p) = 1. A c1 that is not coprime to P,
+// such as 0, yields a shared secret without inverse, so the cipher is
+// malformed rather than the key.
+func coprime(c, p *big.Int) bool {
+	return new(big.Int).GCD(nil, nil, c, p).Cmp(one) == 0
+}
+
+// DecryptBlinded decrypts the passed cipher like Decrypt, but blinds c1
+// before the exponentiation with the secret exponent x, so that the timing
+// of that exponentiation does not depend on the attacker-chosen c1. A random
+// t is chosen and c1 * g^t is raised to x, which yields s * y^t; the factor
+// y^t is divided out afterwards.
+func (priv *PrivateKey) DecryptBlinded(cipher1, cipher2 []byte) ([]byte, error) {
+	c1, c2, err := priv.parseCipher(cipher1, cipher2)
+	if err != nil {
+		return nil, err
 	}
 
+	// choose random integer t from {1...(q-1)}
+	t, err := randExponent(rand.Reader, priv.Order())
+	if err != nil {
+		return nil, err
+	}
+
+	// blinded = c1 * g^t mod p
+	blinded := new(big.Int).Mod(
+		new(big.Int).Mul(c1, new(big.Int).Exp(priv.G, t, priv.P)),
+		priv.P,
+	)
+	// sblinded(inv) = blinded^(-x) = s^(-1) * y^(-t) mod p
+	s := priv.expNegX(blinded)
+	// s(inv) = sblinded^(-1) * y^t mod p
+	s.Mod(s.Mul(s, new(big.Int).Exp(priv.Y, t, priv.P)), priv.P)
+
 	// m = s(inv) * c2 mod p
 	m := new(big.Int).Mod(
 		new(big.Int).Mul(s, c2),
@@ -114,10 +582,11 @@ func (priv *PrivateKey) Decrypt(cipher1, cipher2 []byte) ([]byte, error) {
 // HomomorphicEncTwo performs homomorphic operation over two passed chiphers.
 // Elgamal has multiplicative homomorphic property, so resultant cipher
 // contains the product of two numbers.
+// Ciphers from untrusted parties should be checked with InSubgroup first.
 func (pub *PublicKey) HomomorphicEncTwo(c1, c2, c1dash, c2dash []byte) ([]byte, []byte, error) {
 	cipher1 := new(big.Int).SetBytes(c1)
 	cipher2 := new(big.Int).SetBytes(c2)
-	if cipher1.Cmp(pub.P) == 1 && cipher2.Cmp(pub.P) == 1 { //  (c1, c2) < P
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
 		return nil, nil, ErrCipherLarge
 	}
 
@@ -126,56 +595,167 @@ func (pub *PublicKey) HomomorphicEncTwo(c1, c2, c1dash, c2dash []byte) ([]byte,
 	// by different variable names.
 	cipher1dash := new(big.Int).SetBytes(c1dash)
 	cipher2dash := new(big.Int).SetBytes(c2dash)
-	if cipher1dash.Cmp(pub.P) == 1 && cipher2dash.Cmp(pub.P) == 1 { //  (c1dash, c2dash) < P
+	if cipher1dash.Cmp(pub.P) >= 0 || cipher2dash.Cmp(pub.P) >= 0 { //  (c1dash, c2dash) < P
 		return nil, nil, ErrCipherLarge
 	}
 
 	// C1 = c1 * c1dash mod p
-	C1 := new(big.Int).Mod(
-		new(big.Int).Mul(cipher1, cipher1dash),
-		pub.P,
-	)
+	C1 := mulMod(cipher1, cipher1, cipher1dash, pub.P)
 
 	// C2 = c2 * c2dash mod p
-	C2 := new(big.Int).Mod(
-		new(big.Int).Mul(cipher2, cipher2dash),
-		pub.P,
-	)
+	C2 := mulMod(cipher2, cipher2, cipher2dash, pub.P)
+	return C1.Bytes(), C2.Bytes(), nil
+}
+
+// HomomorphicDivide performs homomorphic division of two passed chiphers.
+// Since Elgamal has multiplicative homomorphic property, resultant cipher
+// contains the first number multiplied by the modular inverse of the second
+// number, m1 * m2^(-1) mod p. It returns an error if either part of the
+// divisor cipher has no modular inverse.
+func (pub *PublicKey) HomomorphicDivide(c1, c2, c1dash, c2dash []byte) ([]byte, []byte, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+
+	cipher1dash := new(big.Int).SetBytes(c1dash)
+	cipher2dash := new(big.Int).SetBytes(c2dash)
+	if cipher1dash.Cmp(pub.P) >= 0 || cipher2dash.Cmp(pub.P) >= 0 { //  (c1dash, c2dash) < P
+		return nil, nil, ErrCipherLarge
+	}
+
+	// c1dash = c1dash^(-1) mod p, c2dash = c2dash^(-1) mod p
+	if cipher1dash.ModInverse(cipher1dash, pub.P) == nil ||
+		cipher2dash.ModInverse(cipher2dash, pub.P) == nil {
+		return nil, nil, errors.New("elgamal: divisor cipher is not invertible")
+	}
+
+	// C1 = c1 * c1dash^(-1) mod p
+	C1 := mulMod(cipher1, cipher1, cipher1dash, pub.P)
+
+	// C2 = c2 * c2dash^(-1) mod p
+	C2 := mulMod(cipher2, cipher2, cipher2dash, pub.P)
 	return C1.Bytes(), C2.Bytes(), nil
 }
 
+// HomomorphicInverse performs homomorphic inversion of the passed chipher.
+// Since Elgamal has multiplicative homomorphic property, resultant cipher
+// contains the modular inverse of the number, m^(-1) mod p. It returns an
+// error if either part of the cipher has no modular inverse.
+func (pub *PublicKey) HomomorphicInverse(c1, c2 []byte) ([]byte, []byte, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+
+	// C1 = c1^(-1) mod p, C2 = c2^(-1) mod p
+	if cipher1.ModInverse(cipher1, pub.P) == nil ||
+		cipher2.ModInverse(cipher2, pub.P) == nil {
+		return nil, nil, errors.New("elgamal: cipher is not invertible")
+	}
+	return cipher1.Bytes(), cipher2.Bytes(), nil
+}
+
 // HommorphicEncMultiple performs homomorphic operation over multiple passed chiphers.
+//
+// Deprecated: HommorphicEncMultiple is misspelled, use HomomorphicEncMultiple instead.
+func (pub *PublicKey) HommorphicEncMultiple(ciphertext [][2][]byte) ([]byte, []byte, error) {
+	return pub.HomomorphicEncMultiple(ciphertext)
+}
+
+// HomomorphicEncMultiple performs homomorphic operation over multiple passed chiphers.
 // Elgamal has multiplicative homomorphic property, so resultant cipher
 // contains the product of multiple numbers.
-func (pub *PublicKey) HommorphicEncMultiple(ciphertext [][2][]byte) ([]byte, []byte, error) {
+// The product is reduced modulo P, so it equals the integer product of the
+// numbers only if that stays below P, see HomomorphicProductFits.
+func (pub *PublicKey) HomomorphicEncMultiple(ciphertext [][2][]byte) ([]byte, []byte, error) {
 	// C1, C2, _ := pub.Encrypt(one.Bytes())
-	C1 := one // since, c = 1^e mod n is equal to 1
-	C2 := one
+	C1 := new(big.Int).Set(one) // since, c = 1^e mod n is equal to 1
+	C2 := new(big.Int).Set(one)
 
+	c1, c2 := new(big.Int), new(big.Int)
 	for i := 0; i < len(ciphertext); i++ {
-		c1 := new(big.Int).SetBytes(ciphertext[i][0])
-		c2 := new(big.Int).SetBytes(ciphertext[i][1])
+		c1.SetBytes(ciphertext[i][0])
+		c2.SetBytes(ciphertext[i][1])
 
-		if c1.Cmp(pub.P) == 1 && c2.Cmp(pub.P) == 1 { //  (c1, c2) < P
+		if c1.Cmp(pub.P) >= 0 || c2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
 			return nil, nil, ErrCipherLarge
 		}
 
 		// C1 = (c1)_1 * (c1)_2 * (c1)_3 ...(c1)_n mod p
-		C1 = new(big.Int).Mod(
-			new(big.Int).Mul(
-				C1,
-				c1),
-			pub.P,
-		)
+		mulMod(C1, C1, c1, pub.P)
 
 		// C2 = (c2)_1 * (c2)_2 * (c2)_3 ...(c2)_n mod p
-		C2 = new(big.Int).Mod(
-			new(big.Int).Mul(
-				C2,
-				c2),
-			pub.P,
-		)
+		mulMod(C2, C2, c2, pub.P)
+	}
+	return C1.Bytes(), C2.Bytes(), nil
+}
+
+// ScalarMul raises the passed cipher to the public constant k. Since Elgamal
+// has multiplicative homomorphic property, resultant cipher contains the
+// original number raised to k modulo P (or k times the number when the
+// cipher is produced by EncryptInt).
+func (pub *PublicKey) ScalarMul(c1, c2 []byte, k *big.Int) ([]byte, []byte, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+	if k.Sign() < 0 {
+		return nil, nil, errors.New("elgamal: scalar must not be negative")
+	}
+
+	// C1 = c1^k mod p
+	C1 := new(big.Int).Exp(cipher1, k, pub.P)
+	// C2 = c2^k mod p
+	C2 := new(big.Int).Exp(cipher2, k, pub.P)
+	return C1.Bytes(), C2.Bytes(), nil
+}
+
+// HomomorphicProductFits reports whether the integer product of the passed
+// plain texts is smaller than P, so that decrypting the homomorphic product
+// of their ciphers yields the integer product rather than a value that has
+// wrapped around modulo P.
+func HomomorphicProductFits(pub *PublicKey, plaintexts [][]byte) bool {
+	product := new(big.Int).Set(one)
+	for _, m := range plaintexts {
+		product.Mul(product, new(big.Int).SetBytes(m))
+		if product.Cmp(pub.P) >= 0 {
+			return false
+		}
 	}
+	return true
+}
+
+// ReRandomize re-randomizes the passed cipher without changing its plain text.
+// The resultant cipher decrypts to the same message, but it is unlinkable
+// to the original cipher.
+func (pub *PublicKey) ReRandomize(c1, c2 []byte) ([]byte, []byte, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+
+	// choose random integer r from {1...(q-1)}
+	r, err := randExponent(rand.Reader, pub.Order())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// C1 = c1 * g^r mod p
+	C1 := new(big.Int).Mod(
+		new(big.Int).Mul(cipher1, new(big.Int).Exp(pub.G, r, pub.P)),
+		pub.P,
+	)
+
+	// C2 = c2 * y^r mod p
+	C2 := new(big.Int).Mod(
+		new(big.Int).Mul(cipher2, new(big.Int).Exp(pub.Y, r, pub.P)),
+		pub.P,
+	)
 	return C1.Bytes(), C2.Bytes(), nil
 }
 
@@ -275,34 +855,107 @@ func (pub *PublicKey) SigVerify(r, s, message []byte) (bool, error) {
 // Author of this code is "Drogunov Igor".
 // Gen emit <p,q,g>.
 // p = 2q + 1, p,q - safe primes
-// g - cyclic group generator Zp
+// g - generator of the subgroup of prime order q in Zp, g != 1 and g^q = 1
 // performs n Miller-Rabin tests with 1 - 1/(4^n) probability false rate.
 // Gain n - bit width for integer & probability rang for MR.
-// It returns p, q, g and write error message.
+// It returns p, q, g and write error message, which is ErrGenerationFailed
+// if no safe prime is found within a bounded number of attempts.
 func Gen(n, probability int) (*big.Int, *big.Int, *big.Int, error) {
-	for {
-		q, err := rand.Prime(rand.Reader, n-1)
+	return genContext(context.Background(), rand.Reader, n, probability)
+}
+
+// genContext is like Gen, but it draws all randomness from random, and it
+// checks the given context before each prime search iteration and returns
+// ctx.Err() once it is done.
+func genContext(ctx context.Context, random io.Reader, n, probability int) (*big.Int, *big.Int, *big.Int, error) {
+	return genStats(ctx, random, n, probability, nil)
+}
+
+// genStats is like genContext, but it records the work done by the search
+// in stats unless stats is nil.
+func genStats(ctx context.Context, random io.Reader, n, probability int, stats *GenStats) (*big.Int, *big.Int, *big.Int, error) {
+	if n < minBitSize || probability < 1 {
+		return nil, nil, nil, ErrInsecureParameters
+	}
+	for attempts := genAttemptsPerBit * n; attempts > 0; attempts-- {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+		q, err := randPrime(ctx, random, n-1)
 		if err != nil {
 			return nil, nil, nil, err
 		}
 		t := new(big.Int).Mul(q, two)
 		p := new(big.Int).Add(t, one)
+		if stats != nil {
+			stats.Candidates++
+			stats.MillerRabinRounds += probability
+		}
 		if p.ProbablyPrime(probability) {
-			for {
-				g, err := rand.Int(rand.Reader, p)
+			for i := 0; i < genGeneratorAttempts; i++ {
+				g, err := rand.Int(random, p)
 				if err != nil {
 					return nil, nil, nil, err
 				}
-				b := new(big.Int).Exp(g, two, p)
-				if b.Cmp(one) == 0 {
+				// g = 0 and g = 1 do not generate the subgroup
+				if g.Cmp(one) <= 0 {
 					continue
 				}
-				b = new(big.Int).Exp(g, q, p)
+				// g^q mod p = 1 with g != 1 means that g has
+				// order exactly q, since q is prime
+				b := new(big.Int).Exp(g, q, p)
 				if b.Cmp(one) == 0 {
 					return p, q, g, nil
 				}
 			}
+			return nil, nil, nil, ErrGenerationFailed
+		}
+	}
+	return nil, nil, nil, ErrGenerationFailed
+}
+
+// randPrime returns a number of the given bit length that is prime with
+// high probability, reading candidates from random. Unlike crypto/rand.Prime,
+// it always honors the given reader, so that a deterministic reader yields
+// a deterministic prime. It returns ErrGenerationFailed if no prime is
+// found within primeAttemptsPerBit * bits candidates, and ctx.Err() once
+// ctx is done.
+func randPrime(ctx context.Context, random io.Reader, bits int) (*big.Int, error) {
+	if bits < 2 {
+		return nil, errors.New("elgamal: prime size must be at least 2-bit")
+	}
+
+	b := uint(bits % 8)
+	if b == 0 {
+		b = 8
+	}
+	bytes := make([]byte, (bits+7)/8)
+	p := new(big.Int)
+
+	for attempts := primeAttemptsPerBit * bits; attempts > 0; attempts-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(random, bytes); err != nil {
+			return nil, err
+		}
+		// clear the bits above the bit length and set the top two bits
+		bytes[0] &= uint8(int(1<<b) - 1)
+		if b >= 2 {
+			bytes[0] |= 3 << (b - 2)
+		} else {
+			bytes[0] |= 1
+			if len(bytes) > 1 {
+				bytes[1] |= 0x80
+			}
+		}
+		// make the value odd
+		bytes[len(bytes)-1] |= 1
+
+		p.SetBytes(bytes)
+		if p.ProbablyPrime(20) {
+			return p, nil
 		}
 	}
-	return nil, nil, nil, errors.New("can't emit <p,q,g>")
+	return nil, ErrGenerationFailed
 }
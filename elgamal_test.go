@@ -0,0 +1,999 @@
+package elgamal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testP is a 1024-bit safe prime p = 2q + 1 with p = 7 mod 8, so that the
+// generator 2 lies in the subgroup of order q. It is far too small for real
+// use, but it keeps the tests fast without searching for a safe prime.
+var testP = hexInt("946496d101ab54df44ab84b1aae200ba8aab72db3ac5144e04c844f8623838e7" +
+	"c036892d232043dc1cda63b47c2959e00eebe31ce93b29070f9e443e9d8a601d" +
+	"36c83176313ce72019bba8d0c9e20f34e177c2f50e156c441d81760f5dc75981" +
+	"afe8bf51cd591347bbdc04412b6955cd83e31034dba2f3657a188d431b8c4aaf")
+
+// testParams returns the group parameters of testP.
+func testParams() *Parameters {
+	return &Parameters{P: testP, Q: new(big.Int).Rsh(testP, 1), G: big.NewInt(2)}
+}
+
+// newTestKey returns a fresh private key in the group of testP, so that
+// tests may modify it freely.
+func newTestKey(t testing.TB) *PrivateKey {
+	t.Helper()
+	priv, err := testParams().GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+func TestGenerateKeyFromZeroReader(t *testing.T) {
+	done := make(chan error, 1)
+	go func() {
+		_, err := GenerateKeyFromReader(zeroReader{}, 1024, 20)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrGenerationFailed) {
+			t.Errorf("got %v, want ErrGenerationFailed", err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("key generation from an all-zero reader did not return")
+	}
+}
+
+func TestRandPrimeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := randPrime(ctx, rand.Reader, 1024); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestValidateMinBits(t *testing.T) {
+	// a 512-bit safe prime from openssl dhparam 512, with generator 2
+	p512 := hexInt("fe8fbef367b5490e49358168c5a5d1df42254b502ec37aedc7332a5b52951a05" +
+		"1574bc862e7a6a9f6bf233ee697abedb56cfa30447d9797b6a9f8f1f64da1ee7")
+	small, err := (&Parameters{P: p512, Q: new(big.Int).Rsh(p512, 1), G: big.NewInt(2)}).GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := GenerateKeyInGroup(MODP2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, priv := range []*PrivateKey{small, newTestKey(t), large} {
+		pub := &priv.PublicKey
+		bits := pub.P.BitLen()
+		if err := pub.Validate(); err != nil {
+			t.Fatalf("%d bits: %v", bits, err)
+		}
+		for _, min := range []int{512, 1024, 2048} {
+			err := pub.ValidateMinBits(min)
+			if bits < min && !errors.Is(err, ErrWeakKey) {
+				t.Errorf("%d bits, minimum %d: got %v, want ErrWeakKey", bits, min, err)
+			}
+			if bits >= min && err != nil {
+				t.Errorf("%d bits, minimum %d: %v", bits, min, err)
+			}
+		}
+	}
+
+	bad := newTestKey(t).PublicKey.Clone()
+	bad.Y = big.NewInt(1)
+	if err := bad.ValidateMinBits(0); err == nil || errors.Is(err, ErrWeakKey) {
+		t.Errorf("ValidateMinBits of a malformed key: got %v", err)
+	}
+}
+
+func TestGenerateKeyExponentRange(t *testing.T) {
+	params := testParams()
+	for i := 0; i < 200; i++ {
+		priv, err := params.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// x in {1...(q-1)}
+		if priv.X.Sign() <= 0 || priv.X.Cmp(params.Q) >= 0 {
+			t.Fatalf("x = %v lies outside of {1...(q-1)}", priv.X)
+		}
+	}
+}
+
+func TestEncryptMessageSize(t *testing.T) {
+	priv := newTestKey(t)
+	tests := []struct {
+		name string
+		m    *big.Int
+		err  error
+	}{
+		{"p-1", new(big.Int).Sub(priv.P, one), nil},
+		{"p", new(big.Int).Set(priv.P), ErrMessageLarge},
+		{"p+1", new(big.Int).Add(priv.P, one), ErrMessageLarge},
+	}
+	for _, tt := range tests {
+		c1, c2, err := priv.Encrypt(tt.m.Bytes())
+		if err != tt.err {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		m, err := priv.DecryptBig(c1, c2)
+		if err != nil || m.Cmp(tt.m) != 0 {
+			t.Errorf("%s: decrypted to %v, %v", tt.name, m, err)
+		}
+	}
+}
+
+func TestDecryptCipherSize(t *testing.T) {
+	priv := newTestKey(t)
+	c1, c2, err := priv.Encrypt([]byte("size"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := priv.P.Bytes()
+	if _, err := priv.Decrypt(large, c2); err != ErrCipherLarge {
+		t.Errorf("oversized c1: got %v, want ErrCipherLarge", err)
+	}
+	if _, err := priv.Decrypt(c1, large); err != ErrCipherLarge {
+		t.Errorf("oversized c2: got %v, want ErrCipherLarge", err)
+	}
+}
+
+func TestReRandomize(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("relay")
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{string(c1) + "," + string(c2): true}
+	for i := 0; i < 5; i++ {
+		d1, d2, err := priv.ReRandomize(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(d1, c1) || bytes.Equal(d2, c2) || seen[string(d1)+","+string(d2)] {
+			t.Error("re-randomized cipher repeats an earlier one")
+		}
+		seen[string(d1)+","+string(d2)] = true
+		m, err := priv.Decrypt(d1, d2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Errorf("got %q, want %q", m, msg)
+		}
+	}
+}
+
+func TestGenerateKeyContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if _, err := GenerateKeyContext(ctx, 4096, 20); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("canceled generation took %v", d)
+	}
+}
+
+func TestGenerateKeyParallel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	priv, err := GenerateKeyParallel(512, 20, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.PublicKey.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("parallel")
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.Decrypt(c1, c2)
+	if err != nil || !bytes.Equal(m, msg) {
+		t.Errorf("got %q, %v, want %q", m, err, msg)
+	}
+}
+
+// failingReader returns err from every read.
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestGenerateKeyParallelWorkerFails(t *testing.T) {
+	errRead := errors.New("read failed")
+	bad := failingReader{errRead}
+	if _, err := generateKeyParallel([]io.Reader{bad, bad}, 512, 20); err != errRead {
+		t.Errorf("all workers failed: got %v, want %v", err, errRead)
+	}
+
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	priv, err := generateKeyParallel([]io.Reader{bad, rand.Reader}, 512, 20)
+	if err != nil {
+		t.Fatalf("one worker failed: %v", err)
+	}
+	if err := priv.PublicKey.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkGenerateKey(b *testing.B) {
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := GenerateKeyParallel(512, 20, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptC1NeverOne(t *testing.T) {
+	priv := newTestKey(t)
+	for i := 0; i < 1000; i++ {
+		c1, _, err := priv.Encrypt([]byte{7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if new(big.Int).SetBytes(c1).Cmp(one) == 0 {
+			t.Fatal("c1 = 1 reveals the message in c2")
+		}
+	}
+}
+
+func TestOrderSetOnGeneration(t *testing.T) {
+	group, err := GenerateKeyInGroup(MODP2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, priv := range []*PrivateKey{newTestKey(t), group} {
+		if priv.Q == nil {
+			t.Fatal("Q is not set")
+		}
+		// p = 2q + 1
+		if new(big.Int).Add(new(big.Int).Lsh(priv.Q, 1), one).Cmp(priv.P) != 0 {
+			t.Errorf("P != 2Q + 1 for a %d-bit key", priv.P.BitLen())
+		}
+	}
+
+	pub := &PublicKey{P: testP, G: big.NewInt(2), Y: big.NewInt(4)}
+	if pub.Order().Cmp(testParams().Q) != 0 {
+		t.Error("Order derived another q from P")
+	}
+}
+
+func TestDecryptBlinded(t *testing.T) {
+	priv := newTestKey(t)
+	for i := 0; i < 50; i++ {
+		c1, c2, err := priv.Encrypt([]byte{byte(i), 0, 9})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := priv.Decrypt(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := priv.DecryptBlinded(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("DecryptBlinded = %x, Decrypt = %x", got, want)
+		}
+	}
+}
+
+func TestScalarMul(t *testing.T) {
+	priv := newTestKey(t)
+	m := big.NewInt(123456789)
+	k := big.NewInt(17)
+	c1, c2, err := priv.Encrypt(m.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1, d2, err := priv.ScalarMul(c1, c2, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := priv.DecryptBig(d1, d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m^k mod p
+	if want := new(big.Int).Exp(m, k, priv.P); got.Cmp(want) != 0 {
+		t.Errorf("got %v, want m^k mod p = %v", got, want)
+	}
+}
+
+func TestDecryptBigLeadingZero(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte{0x00, 0x01, 0x02, 0x03}
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.DecryptBig(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Cmp(new(big.Int).SetBytes(msg)) != 0 {
+		t.Errorf("DecryptBig = %x, want %x", m, msg)
+	}
+	// the integer restores the fixed width, the byte array does not
+	if got := m.FillBytes(make([]byte, len(msg))); !bytes.Equal(got, msg) {
+		t.Errorf("FillBytes = %x, want %x", got, msg)
+	}
+	b, err := priv.Decrypt(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, msg[1:]) {
+		t.Errorf("Decrypt = %x, want %x", b, msg[1:])
+	}
+}
+
+func TestEncryptBatch(t *testing.T) {
+	priv := newTestKey(t)
+	messages := [][]byte{[]byte("a"), []byte("bb"), []byte("ccc")}
+	ciphertext, err := priv.EncryptBatch(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ciphertext) != len(messages) {
+		t.Fatalf("got %d ciphers for %d messages", len(ciphertext), len(messages))
+	}
+	for i, ct := range ciphertext {
+		m, err := priv.Decrypt(ct[0], ct[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, messages[i]) {
+			t.Errorf("cipher %d: got %q, want %q", i, m, messages[i])
+		}
+	}
+	if _, err := priv.EncryptBatch([][]byte{[]byte("ok"), priv.P.Bytes()}); err != ErrMessageLarge {
+		t.Errorf("oversized message: got %v, want ErrMessageLarge", err)
+	}
+}
+
+func BenchmarkEncryptBatch(b *testing.B) {
+	priv := newTestKey(b)
+	messages := make([][]byte, 64)
+	for i := range messages {
+		messages[i] = []byte{byte(i), 1}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := priv.EncryptBatch(messages); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPublicValue(t *testing.T) {
+	priv, err := GenerateKeyInGroup(MODP2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, priv := range []*PrivateKey{newTestKey(t), priv} {
+		// y = g^x mod p
+		if new(big.Int).Exp(priv.G, priv.X, priv.P).Cmp(priv.Y) != 0 {
+			t.Errorf("Y != G^X mod P for a %d-bit key", priv.P.BitLen())
+		}
+	}
+}
+
+func TestHomomorphicEncMultipleAlias(t *testing.T) {
+	priv := newTestKey(t)
+	var ciphertext [][2][]byte
+	for _, m := range []int64{2, 3, 7} {
+		c1, c2, err := priv.Encrypt(big.NewInt(m).Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext = append(ciphertext, [2][]byte{c1, c2})
+	}
+	c1, c2, err := priv.HomomorphicEncMultiple(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1, d2, err := priv.HommorphicEncMultiple(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c1, d1) || !bytes.Equal(c2, d2) {
+		t.Error("deprecated name returned another cipher")
+	}
+	m, err := priv.DecryptBig(c1, c2)
+	if err != nil || m.Int64() != 42 {
+		t.Errorf("product decrypted to %v, %v, want 42", m, err)
+	}
+}
+
+// seedReader is a deterministic stream of bytes, the SHA-256 hash chain
+// of its seed.
+type seedReader struct {
+	h   [32]byte
+	buf []byte
+}
+
+func newSeedReader(seed string) *seedReader {
+	return &seedReader{h: sha256.Sum256([]byte(seed))}
+}
+
+func (r *seedReader) Read(p []byte) (int, error) {
+	for i := range p {
+		if len(r.buf) == 0 {
+			r.h = sha256.Sum256(r.h[:])
+			r.buf = r.h[:]
+		}
+		p[i] = r.buf[0]
+		r.buf = r.buf[1:]
+	}
+	return len(p), nil
+}
+
+func TestGenerateKeyWithRand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	a, err := GenerateKeyWithRand(newSeedReader("seed"), 512, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateKeyWithRand(newSeedReader("seed"), 512, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Equal(b) {
+		t.Error("identical seeds produced different keys")
+	}
+	if err := a.PublicKey.Validate(); err != nil {
+		t.Error(err)
+	}
+	c, err := GenerateKeyFromReader(newSeedReader("seed"), 512, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Equal(c) {
+		t.Error("GenerateKeyFromReader produced another key from the same seed")
+	}
+}
+
+func TestGenerateKeyInsecureParameters(t *testing.T) {
+	tests := []struct{ bits, probability int }{
+		{256, 20},
+		{511, 20},
+		{4096, 0},
+		{4096, -1},
+	}
+	start := time.Now()
+	for _, tt := range tests {
+		if _, err := GenerateKey(tt.bits, tt.probability); err != ErrInsecureParameters {
+			t.Errorf("GenerateKey(%d, %d): got %v, want ErrInsecureParameters", tt.bits, tt.probability, err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("rejecting the parameters took %v", d)
+	}
+}
+
+func TestEncryptWithReader(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("known k")
+	k := big.NewInt(987654321)
+
+	// rand.Int reads k - 1 from the reader, and randExponent adds one
+	qm1 := new(big.Int).Sub(priv.Order(), one)
+	r := bytes.NewReader(new(big.Int).Sub(k, one).FillBytes(make([]byte, (qm1.BitLen()+7)/8)))
+	c1, c2, err := priv.EncryptWithReader(r, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// c1 = g^k mod p, c2 = m * y^k mod p
+	want1 := new(big.Int).Exp(priv.G, k, priv.P)
+	want2 := new(big.Int).Exp(priv.Y, k, priv.P)
+	want2.Mod(want2.Mul(want2, new(big.Int).SetBytes(msg)), priv.P)
+	if !bytes.Equal(c1, want1.Bytes()) || !bytes.Equal(c2, want2.Bytes()) {
+		t.Error("cipher differs from the known answer for k")
+	}
+}
+
+func TestHomomorphicDivide(t *testing.T) {
+	priv := newTestKey(t)
+	a, b := big.NewInt(1000), big.NewInt(7)
+	a1, a2, err := priv.Encrypt(a.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1, b2, err := priv.Encrypt(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, c2, err := priv.HomomorphicDivide(a1, a2, b1, b2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := priv.DecryptBig(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a * b^(-1) mod p
+	want := new(big.Int).ModInverse(b, priv.P)
+	want.Mod(want.Mul(want, a), priv.P)
+	if got.Cmp(want) != 0 {
+		t.Errorf("got %v, want a * b^(-1) mod p = %v", got, want)
+	}
+	if _, _, err := priv.HomomorphicDivide(a1, a2, []byte{0}, b2); err == nil {
+		t.Error("accepted a divisor without inverse")
+	}
+}
+
+func TestReEncryptCheck(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	msg := []byte("audit")
+	c1, c2, k, err := pub.EncryptWithAudit(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.ReEncryptCheck(c1, c2, msg, k) {
+		t.Error("re-encryption with the retained k does not match")
+	}
+	if pub.ReEncryptCheck(c1, c2, []byte("audiT"), k) {
+		t.Error("matched another plain text")
+	}
+	if pub.ReEncryptCheck(c1, c2, msg, new(big.Int).Add(k, one)) {
+		t.Error("matched another k")
+	}
+
+	d1, d2, err := pub.EncryptWithK(msg, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c1, d1) || !bytes.Equal(c2, d2) {
+		t.Error("EncryptWithK is not deterministic")
+	}
+	if _, _, err := pub.EncryptWithK(msg, priv.Order()); err == nil {
+		t.Error("accepted k = q")
+	}
+}
+
+func BenchmarkEncryptAllocs(b *testing.B) {
+	priv := newTestKey(b)
+	msg := []byte("allocations")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := priv.Encrypt(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptAllocs(b *testing.B) {
+	priv := newTestKey(b)
+	c1, c2, err := priv.Encrypt([]byte("allocations"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := priv.Decrypt(c1, c2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHomomorphicEncTwoAllocs(b *testing.B) {
+	priv := newTestKey(b)
+	c1, c2, err := priv.Encrypt([]byte{6})
+	if err != nil {
+		b.Fatal(err)
+	}
+	d1, d2, err := priv.Encrypt([]byte{7})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := priv.HomomorphicEncTwo(c1, c2, d1, d2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenTinyBitsize(t *testing.T) {
+	for _, bits := range []int{2, 8, 16} {
+		done := make(chan error, 1)
+		go func() {
+			_, _, _, err := Gen(bits, 20)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				continue
+			}
+			if err != ErrInsecureParameters && err != ErrGenerationFailed {
+				t.Errorf("Gen(%d): unexpected error %v", bits, err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Gen(%d) did not return", bits)
+		}
+	}
+}
+
+func TestGeneratePQZpWithGenerator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	p, q, g, err := GeneratePQZpWithGenerator(512, 20, two)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).Exp(two, q, p).Cmp(one) == 0 {
+		if g.Cmp(two) != 0 {
+			t.Errorf("g = %v although 2 generates the subgroup", g)
+		}
+	} else if g.Cmp(two) == 0 {
+		t.Error("g = 2 although it does not generate the subgroup")
+	}
+	if new(big.Int).Exp(g, q, p).Cmp(one) != 0 {
+		t.Error("g does not generate the subgroup of order q")
+	}
+}
+
+func TestInSubgroup(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	c1, _, err := priv.Encrypt([]byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []*big.Int{one, priv.G, priv.Y, new(big.Int).SetBytes(c1)} {
+		if !pub.InSubgroup(x) {
+			t.Errorf("%v rejected", x)
+		}
+	}
+
+	pm1 := new(big.Int).Sub(priv.P, one)
+	negY := new(big.Int).Sub(priv.P, priv.Y)
+	for _, x := range []*big.Int{nil, zero, pm1, negY, priv.P, new(big.Int).Add(priv.P, one)} {
+		if pub.InSubgroup(x) {
+			t.Errorf("%v accepted", x)
+		}
+	}
+}
+
+func TestGenGeneratorOrder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for safe primes")
+	}
+	for i := 0; i < 2; i++ {
+		p, q, g, err := Gen(512, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// g != 1 and g^q mod p = 1, so that g has order exactly q
+		if g.Cmp(one) == 0 || new(big.Int).Exp(g, q, p).Cmp(one) != 0 {
+			t.Errorf("g = %v does not have order q", g)
+		}
+	}
+}
+
+// TestOrderConcurrent is meant to be run with go test -race.
+func TestOrderConcurrent(t *testing.T) {
+	pub := &PublicKey{P: testP, G: big.NewInt(2), Y: big.NewInt(4)}
+	want := new(big.Int).Rsh(testP, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if pub.Order().Cmp(want) != 0 {
+				t.Error("Order returned another q")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEncryptWithAudit(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("audit")
+	c1, c2, k, err := priv.EncryptWithAudit(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Sign() <= 0 || k.Cmp(priv.Order()) >= 0 {
+		t.Fatal("k is out of range")
+	}
+	if !bytes.Equal(new(big.Int).Exp(priv.G, k, priv.P).Bytes(), c1) {
+		t.Error("c1 != g^k")
+	}
+	if !priv.ReEncryptCheck(c1, c2, msg, k) {
+		t.Error("re-encryption with k does not reproduce the cipher")
+	}
+}
+
+func TestEncryptZeroMessage(t *testing.T) {
+	priv := newTestKey(t)
+	for _, msg := range [][]byte{nil, {}, {0x00}, {0x00, 0x00}} {
+		if _, _, err := priv.Encrypt(msg); !errors.Is(err, ErrMessageZero) {
+			t.Errorf("Encrypt(%x): got %v, want ErrMessageZero", msg, err)
+		}
+
+		// padded encryption keeps such messages
+		c1, c2, err := priv.EncryptPadded(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := priv.DecryptPadded(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("padded %x decrypted to %x", msg, got)
+		}
+	}
+}
+
+func TestHomomorphicProductFits(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	if !HomomorphicProductFits(pub, [][]byte{{3}, {5}, {7}}) {
+		t.Error("3 * 5 * 7 does not fit")
+	}
+
+	large := new(big.Int).Rsh(priv.P, 4)
+	plaintexts := [][]byte{large.Bytes(), {0x20}}
+	if HomomorphicProductFits(pub, plaintexts) {
+		t.Fatal("(p >> 4) * 32 fits")
+	}
+	var ciphertexts [][2][]byte
+	for _, m := range plaintexts {
+		c1, c2, err := pub.Encrypt(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertexts = append(ciphertexts, [2][]byte{c1, c2})
+	}
+	c1, c2, err := pub.HomomorphicEncMultiple(ciphertexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.Decrypt(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product := new(big.Int).Lsh(large, 5)
+	if new(big.Int).SetBytes(m).Cmp(product) == 0 {
+		t.Error("product did not wrap around modulo p")
+	}
+}
+
+func TestDecryptInvalidCiphertext(t *testing.T) {
+	priv := newTestKey(t)
+	for _, c1 := range [][]byte{nil, {0x00}} {
+		if _, err := priv.Decrypt(c1, []byte{5}); err != ErrInvalidCiphertext {
+			t.Errorf("Decrypt with c1 = %x: got %v, want ErrInvalidCiphertext", c1, err)
+		}
+		if _, err := priv.DecryptBlinded(c1, []byte{5}); err != ErrInvalidCiphertext {
+			t.Errorf("DecryptBlinded with c1 = %x: got %v, want ErrInvalidCiphertext", c1, err)
+		}
+	}
+
+	// c1 = 3 shares a factor with the composite modulus 15
+	bad := &PrivateKey{
+		PublicKey: PublicKey{P: big.NewInt(15), G: big.NewInt(2), Y: big.NewInt(4)},
+		X:         big.NewInt(2),
+	}
+	if _, err := bad.Decrypt([]byte{3}, []byte{1}); err != ErrInvalidCiphertext {
+		t.Errorf("got %v, want ErrInvalidCiphertext", err)
+	}
+}
+
+func TestEncryptReturningSecret(t *testing.T) {
+	priv := newTestKey(t)
+	msg := []byte("secret")
+	c1, c2, s, err := priv.EncryptReturningSecret(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// s = c1^x = y^k mod p
+	if !bytes.Equal(new(big.Int).Exp(new(big.Int).SetBytes(c1), priv.X, priv.P).Bytes(), s) {
+		t.Error("s != y^k")
+	}
+	got, err := priv.SharedSecret(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, s) {
+		t.Error("SharedSecret does not match the secret of the sender")
+	}
+	// c2 = m * s mod p
+	want := new(big.Int).Mul(new(big.Int).SetBytes(msg), new(big.Int).SetBytes(s))
+	if want.Mod(want, priv.P).Cmp(new(big.Int).SetBytes(c2)) != 0 {
+		t.Error("c2 != m * s")
+	}
+}
+
+// TestNoTimeRandomness checks that randomness never comes from the clock or
+// math/rand, so that key generation behaves the same on every architecture,
+// including js/wasm where the clock is coarse.
+func TestNoTimeRandomness(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, imp := range f.Imports {
+			switch path, _ := strconv.Unquote(imp.Path.Value); {
+			case path == "math/rand" || path == "math/rand/v2":
+				t.Errorf("%s imports %s", name, path)
+			case path == "time" && name != "stats.go":
+				t.Errorf("%s imports time", name)
+			}
+		}
+	}
+
+	priv, err := GenerateKeyInGroup(MODP2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHomomorphicInverse(t *testing.T) {
+	priv := newTestKey(t)
+	c1, c2, err := priv.Encrypt([]byte{7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i1, i2, err := priv.HomomorphicInverse(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.DecryptBig(i1, i2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).ModInverse(big.NewInt(7), priv.P); m.Cmp(want) != 0 {
+		t.Errorf("got %v, want 7^(-1) mod p", m)
+	}
+	if _, _, err := priv.HomomorphicInverse(nil, c2); err == nil {
+		t.Error("inverted a cipher with c1 = 0")
+	}
+}
+
+func FuzzDecrypt(f *testing.F) {
+	priv := newTestKey(f)
+	c1, c2, err := priv.Encrypt([]byte("seed"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(c1, c2)
+	f.Add([]byte{}, []byte{})
+	f.Add([]byte{0x00}, []byte{0x01})
+	f.Add(priv.P.Bytes(), c2)
+	f.Add(new(big.Int).Sub(priv.P, one).Bytes(), []byte{0x00})
+	f.Fuzz(func(t *testing.T, c1, c2 []byte) {
+		for _, decrypt := range []func(c1, c2 []byte) ([]byte, error){
+			priv.Decrypt,
+			priv.DecryptBlinded,
+			priv.DecryptPadded,
+			priv.DecryptQR,
+			priv.DecryptVerify,
+		} {
+			m, err := decrypt(c1, c2)
+			if err == nil && new(big.Int).SetBytes(m).Cmp(priv.P) >= 0 {
+				t.Fatalf("plain text %x is not smaller than p", m)
+			}
+		}
+		priv.DecryptLong([][2][]byte{{c1, c2}})
+		priv.DecryptFromBytes(append(c1, c2...))
+		priv.DecryptHybrid(append(c1, c2...))
+	})
+}
+
+func TestIsSafePrime(t *testing.T) {
+	for _, p := range []*big.Int{big.NewInt(5), big.NewInt(7), big.NewInt(23), big.NewInt(107), testP, MODP2048.P()} {
+		if !IsSafePrime(p, 20) {
+			t.Errorf("rejected the safe prime %v", p)
+		}
+	}
+	// 13 and 29 are prime, but (p-1)/2 is not
+	for _, p := range []*big.Int{nil, big.NewInt(-7), big.NewInt(0), big.NewInt(2), big.NewInt(3), big.NewInt(13), big.NewInt(29), big.NewInt(15)} {
+		if IsSafePrime(p, 20) {
+			t.Errorf("accepted %v", p)
+		}
+	}
+	if IsSafePrime(new(big.Int).Add(testP, two), 20) {
+		t.Error("accepted p + 2")
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	priv := newTestKey(t)
+	c1, c2, err := priv.Encrypt([]byte("sentinel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outOfRange := priv.Clone()
+	outOfRange.X = new(big.Int).Sub(priv.P, one)
+	for _, bad := range []*PrivateKey{outOfRange, {PublicKey: priv.PublicKey}} {
+		if _, err := bad.Decrypt(c1, c2); !errors.Is(err, ErrInvalidPrivateKey) {
+			t.Errorf("Decrypt with x = %v: got %v, want ErrInvalidPrivateKey", bad.X, err)
+		}
+		if _, err := bad.DecryptBlinded(c1, c2); !errors.Is(err, ErrInvalidPrivateKey) {
+			t.Errorf("DecryptBlinded with x = %v: got %v, want ErrInvalidPrivateKey", bad.X, err)
+		}
+	}
+	if _, err := priv.Decrypt([]byte{0x00}, c2); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("c1 = 0: got %v, want ErrInvalidCiphertext", err)
+	}
+	if _, err := priv.Decrypt(c1, []byte{0x00}); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("c2 = 0: got %v, want ErrInvalidCiphertext", err)
+	}
+	// ErrGenerationFailed is covered by TestGenerateKeyFromZeroReader
+
+	// the wording of the errors is part of the API
+	for err, want := range map[error]string{
+		ErrInvalidPrivateKey: "elgamal: invalid private key",
+		ErrInvalidCiphertext: "elgamal: invalid cipher",
+		ErrGenerationFailed:  "elgamal: can't emit <p,q,g>",
+	} {
+		if err.Error() != want {
+			t.Errorf("got %q, want %q", err, want)
+		}
+	}
+}
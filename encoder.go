@@ -0,0 +1,125 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// MessageEncoder maps plain texts to the integers that are encrypted and
+// back, so that applications can choose how messages are represented in
+// the group. Decode must reverse Encode.
+type MessageEncoder interface {
+	Encode(message []byte) (*big.Int, error)
+	Decode(m *big.Int) ([]byte, error)
+}
+
+// RawEncoder encodes a message as the integer of its big-endian bytes,
+// which is the encoding of Encrypt. Leading zero bytes are not preserved.
+type RawEncoder struct{}
+
+func (RawEncoder) Encode(message []byte) (*big.Int, error) {
+	return new(big.Int).SetBytes(message), nil
+}
+
+func (RawEncoder) Decode(m *big.Int) ([]byte, error) {
+	return m.Bytes(), nil
+}
+
+// QREncoder encodes a message into the subgroup of prime order q of Key,
+// which is the encoding of EncryptQR.
+type QREncoder struct {
+	Key *PublicKey
+}
+
+func (e QREncoder) Encode(message []byte) (*big.Int, error) {
+	q := e.Key.Order()
+	m := new(big.Int).SetBytes(message)
+	if m.Cmp(q) >= 0 { //  m < Q
+		return nil, ErrMessageLarge
+	}
+
+	// encoded = m + 1 if (m + 1)^q mod p = 1, p - (m + 1) otherwise
+	encoded := m.Add(m, one)
+	if new(big.Int).Exp(encoded, q, e.Key.P).Cmp(one) != 0 {
+		encoded.Sub(e.Key.P, encoded)
+	}
+	return encoded, nil
+}
+
+func (e QREncoder) Decode(encoded *big.Int) ([]byte, error) {
+	if encoded.Sign() <= 0 || encoded.Cmp(e.Key.P) >= 0 {
+		return nil, errors.New("elgamal: invalid subgroup encoding")
+	}
+
+	// m + 1 = encoded if encoded <= q, p - encoded otherwise
+	m := new(big.Int).Set(encoded)
+	if m.Cmp(e.Key.Order()) > 0 {
+		m.Sub(e.Key.P, m)
+	}
+	return m.Sub(m, one).Bytes(), nil
+}
+
+// ExponentialEncoder encodes a message, read as a big-endian integer m,
+// as g^m mod p of Key, which is the encoding of EncryptInt. Decoding solves
+// the discrete logarithm for m in {0...Max}, or {0...MaxDecryptInt} if Max
+// is zero.
+type ExponentialEncoder struct {
+	Key *PublicKey
+	Max int64
+}
+
+func (e ExponentialEncoder) Encode(message []byte) (*big.Int, error) {
+	// encoded = g^m mod p
+	m := new(big.Int).SetBytes(message)
+	return new(big.Int).Exp(e.Key.G, m, e.Key.P), nil
+}
+
+func (e ExponentialEncoder) Decode(encoded *big.Int) ([]byte, error) {
+	max := e.Max
+	if max == 0 {
+		max = MaxDecryptInt
+	}
+
+	// m = log_g(g^m) mod p
+	m, err := DiscreteLog(e.Key.G, encoded, e.Key.P, max)
+	if err != nil {
+		return nil, err
+	}
+	return m.Bytes(), nil
+}
+
+// EncryptWithEncoder is like Encrypt, but it maps the message to an
+// integer with enc, which may be nil for the RawEncoder.
+func (pub *PublicKey) EncryptWithEncoder(enc MessageEncoder, message []byte) ([]byte, []byte, error) {
+	if enc == nil {
+		enc = RawEncoder{}
+	}
+	m, err := enc.Encode(message)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.Sign() < 0 || m.Cmp(pub.P) >= 0 { //  0 <= m < P
+		return nil, nil, ErrMessageLarge
+	}
+
+	// choose random integer k from {1...(q-1)}
+	k, err := randExponent(rand.Reader, pub.Order())
+	if err != nil {
+		return nil, nil, err
+	}
+	return pub.encrypt(m, k)
+}
+
+// DecryptWithEncoder decrypts a cipher produced by EncryptWithEncoder
+// with the same encoder, which may be nil for the RawEncoder.
+func (priv *PrivateKey) DecryptWithEncoder(enc MessageEncoder, cipher1, cipher2 []byte) ([]byte, error) {
+	if enc == nil {
+		enc = RawEncoder{}
+	}
+	m, err := priv.DecryptBig(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+	return enc.Decode(m)
+}
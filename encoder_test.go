@@ -0,0 +1,38 @@
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoders(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	for _, tt := range []struct {
+		name string
+		enc  MessageEncoder
+	}{
+		{"nil", nil},
+		{"raw", RawEncoder{}},
+		{"subgroup", QREncoder{Key: pub}},
+		{"exponential", ExponentialEncoder{Key: pub, Max: 1 << 20}},
+	} {
+		for _, msg := range [][]byte{{0x01}, {0x12, 0x34}} {
+			c1, c2, err := pub.EncryptWithEncoder(tt.enc, msg)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			got, err := priv.DecryptWithEncoder(tt.enc, c1, c2)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if !bytes.Equal(got, msg) {
+				t.Errorf("%s: got %x, want %x", tt.name, got, msg)
+			}
+		}
+	}
+
+	if _, _, err := pub.EncryptWithEncoder(QREncoder{Key: pub}, priv.Order().Bytes()); err != ErrMessageLarge {
+		t.Errorf("subgroup encoding of q: got %v, want ErrMessageLarge", err)
+	}
+}
@@ -0,0 +1,123 @@
+package elgamal
+
+import (
+	"errors"
+	"math/big"
+)
+
+// MaxDecryptInt is the largest plaintext that DecryptInt searches for while
+// solving the discrete logarithm of the decrypted value g^m mod p. Use
+// DecryptIntMax for another bound.
+const MaxDecryptInt int64 = 1 << 24
+
+var ErrDiscreteLogNotFound = errors.New("elgamal: discrete logarithm not found in range")
+var ErrPlaintextTooLarge = errors.New("elgamal: plaintext is larger than the search bound")
+
+// EncryptInt encrypts the integer m using exponential ElGamal. The message
+// is encoded as g^m mod p before encryption, so that the homomorphic product
+// of two such ciphers contains the sum of the original numbers. The value of
+// m must be small enough to brute-force the discrete logarithm on decryption.
+func (pub *PublicKey) EncryptInt(m *big.Int) ([]byte, []byte, error) {
+	if m.Sign() < 0 {
+		return nil, nil, errors.New("elgamal: message must not be negative")
+	}
+
+	// encoded = g^m mod p
+	encoded := new(big.Int).Exp(pub.G, m, pub.P)
+	return pub.Encrypt(encoded.Bytes())
+}
+
+// DecryptInt decrypts a cipher produced by EncryptInt. It recovers g^m mod p
+// and then solves the discrete logarithm for m in {0...MaxDecryptInt}. It
+// returns an error if m lies outside of this range.
+func (priv *PrivateKey) DecryptInt(cipher1, cipher2 []byte) (*big.Int, error) {
+	encoded, err := priv.Decrypt(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+
+	// m = log_g(g^m) mod p
+	return DiscreteLog(priv.G, new(big.Int).SetBytes(encoded), priv.P, MaxDecryptInt)
+}
+
+// DecryptIntMax is like DecryptInt, but it solves the discrete logarithm for
+// m in {0...max} instead of {0...MaxDecryptInt}. The search takes time and
+// memory in the order of sqrt(max), so callers should size max to the domain
+// of their values, e.g. the number of voters when tallying votes. It returns
+// ErrPlaintextTooLarge if m lies outside of this range.
+func (priv *PrivateKey) DecryptIntMax(cipher1, cipher2 []byte, max int64) (*big.Int, error) {
+	encoded, err := priv.Decrypt(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+
+	// m = log_g(g^m) mod p
+	m, err := DiscreteLog(priv.G, new(big.Int).SetBytes(encoded), priv.P, max)
+	if err == ErrDiscreteLogNotFound {
+		return nil, ErrPlaintextTooLarge
+	}
+	return m, err
+}
+
+// AddConstant adds the known constant c to the integer encrypted in the
+// passed cipher without decrypting it, by multiplying c2 with g^c mod p.
+// It only makes sense for ciphers produced by EncryptInt, for which the
+// resultant cipher decrypts with DecryptInt to m + c.
+func (pub *PublicKey) AddConstant(c1, c2 []byte, c *big.Int) ([]byte, []byte, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+	if c.Sign() < 0 {
+		return nil, nil, errors.New("elgamal: constant must not be negative")
+	}
+
+	// C2 = c2 * g^c mod p
+	C2 := mulMod(cipher2, cipher2, new(big.Int).Exp(pub.G, c, pub.P), pub.P)
+	return cipher1.Bytes(), C2.Bytes(), nil
+}
+
+// DiscreteLog finds x in {0...max} such that g^x mod p = h. It implements
+// the baby-step giant-step algorithm and therefore performs O(sqrt(max))
+// multiplications and table lookups. It returns ErrDiscreteLogNotFound if
+// there is no solution within the given range.
+func DiscreteLog(g, h, p *big.Int, max int64) (*big.Int, error) {
+	if max < 0 {
+		return nil, ErrDiscreteLogNotFound
+	}
+
+	// m = ceil(sqrt(max + 1))
+	m := new(big.Int).Sqrt(big.NewInt(max))
+	m.Add(m, one)
+
+	// baby steps: table[g^j mod p] = j for j in {0...(m-1)}
+	table := make(map[string]int64, m.Int64())
+	e := new(big.Int).Set(one)
+	for j := int64(0); j < m.Int64(); j++ {
+		if _, ok := table[string(e.Bytes())]; !ok {
+			table[string(e.Bytes())] = j
+		}
+		e.Mod(e.Mul(e, g), p)
+	}
+
+	// factor = g^(-m) mod p
+	factor := new(big.Int).Exp(g, m, p)
+	if factor.ModInverse(factor, p) == nil {
+		return nil, errors.New("elgamal: generator is not invertible")
+	}
+
+	// giant steps: gamma = h * g^(-i*m) mod p for i in {0...m}
+	gamma := new(big.Int).Mod(h, p)
+	for i := int64(0); i <= m.Int64(); i++ {
+		if j, ok := table[string(gamma.Bytes())]; ok {
+			x := i*m.Int64() + j
+			if x > max {
+				break
+			}
+			return big.NewInt(x), nil
+		}
+		gamma.Mod(gamma.Mul(gamma, factor), p)
+	}
+	return nil, ErrDiscreteLogNotFound
+}
@@ -0,0 +1,76 @@
+package elgamal
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDiscreteLog(t *testing.T) {
+	p, g := testP, big.NewInt(2)
+	const max = 4000000
+	for _, x := range []int64{0, 1, 2, 999, 123456, 3999999, max} {
+		h := new(big.Int).Exp(g, big.NewInt(x), p)
+		got, err := DiscreteLog(g, h, p, max)
+		if err != nil {
+			t.Errorf("log of 2^%d: %v", x, err)
+			continue
+		}
+		if got.Int64() != x {
+			t.Errorf("log of 2^%d = %v", x, got)
+		}
+	}
+
+	h := new(big.Int).Exp(g, big.NewInt(max+1), p)
+	if _, err := DiscreteLog(g, h, p, max); err != ErrDiscreteLogNotFound {
+		t.Errorf("out of range: got %v, want ErrDiscreteLogNotFound", err)
+	}
+	if _, err := DiscreteLog(g, one, p, -1); err != ErrDiscreteLogNotFound {
+		t.Errorf("negative max: got %v, want ErrDiscreteLogNotFound", err)
+	}
+}
+
+func TestAddConstant(t *testing.T) {
+	priv := newTestKey(t)
+	c1, c2, err := priv.EncryptInt(big.NewInt(1200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1, d2, err := priv.AddConstant(c1, c2, big.NewInt(34))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.DecryptInt(d1, d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 1234 {
+		t.Errorf("got %v, want 1234", m)
+	}
+	if _, _, err := priv.AddConstant(c1, c2, big.NewInt(-1)); err == nil {
+		t.Error("accepted a negative constant")
+	}
+}
+
+func TestDecryptIntMaxBoundary(t *testing.T) {
+	priv := newTestKey(t)
+	const max = 5000
+	c1, c2, err := priv.EncryptInt(big.NewInt(max))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.DecryptIntMax(c1, c2, max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != max {
+		t.Errorf("got %v, want %d", m, max)
+	}
+
+	c1, c2, err = priv.EncryptInt(big.NewInt(max + 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := priv.DecryptIntMax(c1, c2, max); err != ErrPlaintextTooLarge {
+		t.Errorf("m = max + 1: got %v, want ErrPlaintextTooLarge", err)
+	}
+}
@@ -0,0 +1,97 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// Group identifies a standard MODP group from RFC 3526. Each group
+// is a safe prime P = 2Q + 1 with the generator G = 2, which generates
+// the prime-order subgroup of Zp. Using a named group avoids the
+// expensive safe prime search performed by GenerateKey.
+type Group int
+
+const (
+	MODP2048 Group = iota + 1 // 2048-bit MODP Group (RFC 3526, section 3)
+	MODP3072                  // 3072-bit MODP Group (RFC 3526, section 4)
+	MODP4096                  // 4096-bit MODP Group (RFC 3526, section 5)
+)
+
+// modp2048P is the prime of the 2048-bit MODP Group in hex.
+const modp2048P = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+	"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+	"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+	"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+	"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+	"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+	"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+	"3995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF"
+
+// modp3072P is the prime of the 3072-bit MODP Group in hex.
+const modp3072P = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+	"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+	"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+	"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+	"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+	"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+	"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+	"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+	"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+	"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+	"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+	"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF"
+
+// modp4096P is the prime of the 4096-bit MODP Group in hex.
+const modp4096P = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+	"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+	"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+	"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+	"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+	"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+	"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+	"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+	"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+	"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+	"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+	"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D7" +
+	"88719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA2583E9CA2AD44CE8" +
+	"DBBBC2DB04DE8EF92E8EFC141FBECAA6287C59474E6BC05D99B2964FA090C3A2" +
+	"233BA186515BE7ED1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA9" +
+	"93B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934063199FFFFFFFFFFFFFFFF"
+
+// P returns the prime modulus of the group.
+// It returns nil if the group is unknown.
+func (g Group) P() *big.Int {
+	var h string
+	switch g {
+	case MODP2048:
+		h = modp2048P
+	case MODP3072:
+		h = modp3072P
+	case MODP4096:
+		h = modp4096P
+	default:
+		return nil
+	}
+	p, _ := new(big.Int).SetString(h, 16)
+	return p
+}
+
+// G returns the generator of the group, which is 2 for all RFC 3526 groups.
+func (g Group) G() *big.Int {
+	return big.NewInt(2)
+}
+
+// GenerateKeyInGroup generates elgamal private key in the given named
+// group. Only the secret exponent x is chosen at random, therefore it
+// is considerably faster than GenerateKey.
+func GenerateKeyInGroup(g Group) (*PrivateKey, error) {
+	p := g.P()
+	if p == nil {
+		return nil, errors.New("elgamal: unknown group")
+	}
+	// q = (p - 1) / 2
+	q := new(big.Int).Rsh(p, 1)
+	return newPrivateKey(rand.Reader, p, q, g.G())
+}
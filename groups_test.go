@@ -0,0 +1,34 @@
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	for _, g := range []Group{MODP2048, MODP3072, MODP4096} {
+		if !IsSafePrime(g.P(), 2) {
+			t.Errorf("group %d: P is not a safe prime", g)
+			continue
+		}
+		priv, err := GenerateKeyInGroup(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := []byte("named group")
+		c1, c2, err := priv.Encrypt(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := priv.Decrypt(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Errorf("group %d: got %q, want %q", g, m, msg)
+		}
+	}
+	if _, err := GenerateKeyInGroup(Group(0)); err == nil {
+		t.Error("accepted an unknown group")
+	}
+}
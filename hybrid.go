@@ -0,0 +1,119 @@
+package elgamal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// hybridKeySize is the size of the AES-256 session key of EncryptHybrid.
+const hybridKeySize = 32
+
+// EncryptHybrid encrypts a plain text of arbitrary length. A random AES-256
+// session key is encrypted with Elgamal and the message is encrypted with
+// AES-GCM under that key. The returned blob is laid out as
+// len(c1) || c1 || len(c2) || c2 || nonce || AES-GCM cipher, where the
+// lengths are 4-byte big-endian integers.
+func (pub *PublicKey) EncryptHybrid(message []byte) ([]byte, error) {
+	return pub.EncryptHybridAAD(message, nil)
+}
+
+// EncryptHybridAAD is like EncryptHybrid, but it authenticates the passed
+// associated data, such as a recipient ID, along with the message. The
+// associated data is not part of the blob, and DecryptHybridAAD fails
+// unless it is given the same associated data, so that the blob cannot be
+// replayed in another context.
+func (pub *PublicKey) EncryptHybridAAD(message, aad []byte) ([]byte, error) {
+	aead, header, err := pub.newSessionKey()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	blob := append(header, nonce...)
+	return aead.Seal(blob, nonce, message, aad), nil
+}
+
+// DecryptHybrid decrypts a blob produced by EncryptHybrid. It returns an
+// error if the blob is malformed or fails AES-GCM authentication.
+func (priv *PrivateKey) DecryptHybrid(blob []byte) ([]byte, error) {
+	return priv.DecryptHybridAAD(blob, nil)
+}
+
+// DecryptHybridAAD decrypts a blob produced by EncryptHybridAAD. It returns
+// an error if the blob is malformed or fails AES-GCM authentication, which
+// includes the case that aad differs from the associated data used to
+// encrypt.
+func (priv *PrivateKey) DecryptHybridAAD(blob, aad []byte) ([]byte, error) {
+	c1, rest, err := readBytes(blob)
+	if err != nil {
+		return nil, err
+	}
+	c2, rest, err := readBytes(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := priv.openSessionKey(c1, c2)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, errBinaryTruncated
+	}
+	nonce, sealed := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, aad)
+}
+
+// newSessionKey generates a random AES-256 session key and encrypts it
+// with Elgamal. It returns AES-GCM under the session key along with the
+// encrypted session key encoded as len(c1) || c1 || len(c2) || c2.
+func (pub *PublicKey) newSessionKey() (cipher.AEAD, []byte, error) {
+	if pub.P.BitLen() <= hybridKeySize*8 {
+		return nil, nil, errors.New("elgamal: public key is too small for hybrid encryption")
+	}
+
+	key := make([]byte, hybridKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+	c1, c2, err := pub.Encrypt(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := newHybridAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, joinCipher(c1, c2), nil
+}
+
+// openSessionKey decrypts the session key encrypted by newSessionKey
+// and returns AES-GCM under that key.
+func (priv *PrivateKey) openSessionKey(c1, c2 []byte) (cipher.AEAD, error) {
+	key, err := priv.Decrypt(c1, c2)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) > hybridKeySize {
+		return nil, errors.New("elgamal: invalid hybrid session key")
+	}
+	// restore leading zero bytes of the session key
+	key = append(make([]byte, hybridKeySize-len(key)), key...)
+	return newHybridAEAD(key)
+}
+
+// newHybridAEAD returns AES-GCM under the given session key.
+func newHybridAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,67 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestHybrid(t *testing.T) {
+	priv := newTestKey(t)
+	large := make([]byte, 1<<20)
+	if _, err := rand.Read(large); err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range [][]byte{{}, []byte("hybrid"), large} {
+		blob, err := priv.EncryptHybrid(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := priv.DecryptHybrid(blob)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(msg), err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("%d bytes: message changed in the round trip", len(msg))
+		}
+	}
+}
+
+func TestHybridTampered(t *testing.T) {
+	priv := newTestKey(t)
+	blob, err := priv.EncryptHybrid([]byte("tamper"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{len(blob) - 1, len(blob) - 20} {
+		bad := bytes.Clone(blob)
+		bad[i] ^= 1
+		if _, err := priv.DecryptHybrid(bad); err == nil {
+			t.Errorf("accepted a blob modified at byte %d", i)
+		}
+	}
+	if _, err := priv.DecryptHybrid(blob[:len(blob)-1]); err == nil {
+		t.Error("accepted a truncated blob")
+	}
+}
+
+func TestHybridAAD(t *testing.T) {
+	priv := newTestKey(t)
+	blob, err := priv.EncryptHybridAAD([]byte("label"), []byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := priv.DecryptHybridAAD(blob, []byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte("label")) {
+		t.Error("message changed in the round trip")
+	}
+	if _, err := priv.DecryptHybridAAD(blob, []byte("mallory")); err == nil {
+		t.Error("accepted a blob under another label")
+	}
+	if _, err := priv.DecryptHybrid(blob); err == nil {
+		t.Error("accepted a labeled blob without its label")
+	}
+}
@@ -0,0 +1,159 @@
+package elgamal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+)
+
+// publicKeyJSON is the JSON form of an Elgamal public key. Each
+// parameter is the base64 encoding of its big-endian bytes.
+type publicKeyJSON struct {
+	P *string `json:"p"`
+	G *string `json:"g"`
+	Y *string `json:"y"`
+	Q *string `json:"q,omitempty"`
+}
+
+// privateKeyJSON is the JSON form of an Elgamal private key.
+type privateKeyJSON struct {
+	P *string `json:"p"`
+	G *string `json:"g"`
+	Y *string `json:"y"`
+	Q *string `json:"q,omitempty"`
+	X *string `json:"x"`
+}
+
+// encodeJSONInt returns the base64 encoding of the big-endian bytes of x.
+func encodeJSONInt(x *big.Int) *string {
+	s := base64.StdEncoding.EncodeToString(x.Bytes())
+	return &s
+}
+
+// decodeJSONInt decodes a base64 encoded integer of the named field.
+// It returns an error if the field is missing or is not valid base64.
+func decodeJSONInt(name string, s *string) (*big.Int, error) {
+	if s == nil {
+		return nil, errors.New("elgamal: missing JSON field " + name)
+	}
+	b, err := base64.StdEncoding.DecodeString(*s)
+	if err != nil {
+		return nil, errors.New("elgamal: invalid base64 in JSON field " + name)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// decodeOptionalJSONInt is like decodeJSONInt, but it returns
+// nil without an error if the field is missing.
+func decodeOptionalJSONInt(name string, s *string) (*big.Int, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return decodeJSONInt(name, s)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The parameters
+// are encoded as base64 strings, e.g. {"p":"...","g":"...","y":"...","q":"..."}.
+func (pub *PublicKey) MarshalJSON() ([]byte, error) {
+	if pub.P == nil || pub.G == nil || pub.Y == nil {
+		return nil, errors.New("elgamal: missing public key parameters")
+	}
+	return json.Marshal(publicKeyJSON{
+		P: encodeJSONInt(pub.P),
+		G: encodeJSONInt(pub.G),
+		Y: encodeJSONInt(pub.Y),
+		Q: encodeJSONInt(pub.Order()),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It returns
+// an error if any parameter is missing or is not valid base64. The
+// parameter Q is optional and is derived from P when it is absent.
+func (pub *PublicKey) UnmarshalJSON(data []byte) error {
+	var key publicKeyJSON
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+
+	p, err := decodeJSONInt("p", key.P)
+	if err != nil {
+		return err
+	}
+	g, err := decodeJSONInt("g", key.G)
+	if err != nil {
+		return err
+	}
+	y, err := decodeJSONInt("y", key.Y)
+	if err != nil {
+		return err
+	}
+	q, err := decodeOptionalJSONInt("q", key.Q)
+	if err != nil {
+		return err
+	}
+
+	pub.P = p
+	pub.G = g
+	pub.Y = y
+	pub.Q = q
+	pub.precomputed = nil
+	pub.lazyQ = atomic.Value{}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The parameters
+// are encoded as base64 strings, e.g. {"p":"...","g":"...","y":"...","q":"...","x":"..."}.
+func (priv *PrivateKey) MarshalJSON() ([]byte, error) {
+	if priv.P == nil || priv.G == nil || priv.Y == nil || priv.X == nil {
+		return nil, errors.New("elgamal: missing private key parameters")
+	}
+	return json.Marshal(privateKeyJSON{
+		P: encodeJSONInt(priv.P),
+		G: encodeJSONInt(priv.G),
+		Y: encodeJSONInt(priv.Y),
+		Q: encodeJSONInt(priv.Order()),
+		X: encodeJSONInt(priv.X),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It returns
+// an error if any parameter is missing or is not valid base64. The
+// parameter Q is optional and is derived from P when it is absent.
+func (priv *PrivateKey) UnmarshalJSON(data []byte) error {
+	var key privateKeyJSON
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+
+	p, err := decodeJSONInt("p", key.P)
+	if err != nil {
+		return err
+	}
+	g, err := decodeJSONInt("g", key.G)
+	if err != nil {
+		return err
+	}
+	y, err := decodeJSONInt("y", key.Y)
+	if err != nil {
+		return err
+	}
+	q, err := decodeOptionalJSONInt("q", key.Q)
+	if err != nil {
+		return err
+	}
+	x, err := decodeJSONInt("x", key.X)
+	if err != nil {
+		return err
+	}
+
+	priv.PublicKey = PublicKey{
+		G: g,
+		P: p,
+		Y: y,
+		Q: q,
+	}
+	priv.X = x
+	return nil
+}
@@ -0,0 +1,47 @@
+package elgamal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	priv := newTestKey(t)
+
+	data, err := json.Marshal(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got PrivateKey
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) || got.Q.Cmp(priv.Q) != 0 {
+		t.Error("private key changed in the JSON round trip")
+	}
+
+	data, err = json.Marshal(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pub PublicKey
+	if err := json.Unmarshal(data, &pub); err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) || pub.Q.Cmp(priv.Q) != 0 {
+		t.Error("public key changed in the JSON round trip")
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"g":"Ag==","y":"Aw=="}`,
+		`{"p":"!!","g":"Ag==","y":"Aw=="}`,
+		`[]`,
+	} {
+		var pub PublicKey
+		if err := json.Unmarshal([]byte(data), &pub); err == nil {
+			t.Errorf("accepted %s", data)
+		}
+	}
+}
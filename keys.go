@@ -0,0 +1,167 @@
+package elgamal
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// bigEqual reports whether a and b are equal. Two nil values are equal.
+func bigEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// Equal reports whether pub and x have the same value. It matches the
+// Equal method of the public keys of the standard library.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok || xx == nil {
+		return false
+	}
+	return bigEqual(pub.P, xx.P) &&
+		bigEqual(pub.G, xx.G) &&
+		bigEqual(pub.Y, xx.Y) &&
+		bigEqual(pub.Order(), xx.Order())
+}
+
+// Equal reports whether priv and x have the same value. It matches the
+// Equal method of the private keys of the standard library.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok || xx == nil {
+		return false
+	}
+	return priv.PublicKey.Equal(&xx.PublicKey) && bigEqual(priv.X, xx.X)
+}
+
+// NewPublicKey returns the public key <p,g,y> after checking it with
+// Validate. It returns the error of Validate if the parameters are
+// inconsistent.
+func NewPublicKey(p, g, y *big.Int) (*PublicKey, error) {
+	pub := &PublicKey{G: g, P: p, Y: y}
+	if err := pub.Validate(); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// NewPrivateKey returns the private key with the secret exponent x in the
+// group <p,g>, computing y = g^x mod p. The secret exponent must lie in
+// {1...(q-1)}, and the resultant public key is checked with Validate.
+func NewPrivateKey(p, g, x *big.Int) (*PrivateKey, error) {
+	if p == nil || g == nil || x == nil {
+		return nil, errors.New("elgamal: missing private key parameters")
+	}
+	if x.Sign() <= 0 || x.Cmp(new(big.Int).Rsh(p, 1)) >= 0 {
+		return nil, errors.New("elgamal: secret exponent is out of range")
+	}
+
+	// y = g^x mod p
+	y := new(big.Int).Exp(g, x, p)
+	pub, err := NewPublicKey(p, g, y)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{PublicKey: *pub, X: x}, nil
+}
+
+// cloneInt returns a copy of x that does not share its memory. It returns
+// nil if x is nil.
+func cloneInt(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+// Clone returns a deep copy of the public key, so that changing any of its
+// parameters does not affect the original and vice versa. The precomputed
+// tables are not copied, so Precompute must be called again on the copy.
+func (pub *PublicKey) Clone() *PublicKey {
+	return &PublicKey{
+		G: cloneInt(pub.G),
+		P: cloneInt(pub.P),
+		Y: cloneInt(pub.Y),
+		Q: cloneInt(pub.Q),
+	}
+}
+
+// Clone returns a deep copy of the private key, like PublicKey.Clone.
+func (priv *PrivateKey) Clone() *PrivateKey {
+	return &PrivateKey{
+		PublicKey:  *priv.PublicKey.Clone(),
+		X:          cloneInt(priv.X),
+		SecureMode: priv.SecureMode,
+	}
+}
+
+// Size returns the size in bytes of the modulus P, which is also
+// the maximum size of each part of a cipher.
+func (pub *PublicKey) Size() int {
+	return (pub.P.BitLen() + 7) / 8
+}
+
+// CiphertextLen returns the length in bytes of each part of a cipher
+// produced by EncryptFixed. It equals Size, the largest length of each
+// part of any cipher of the key.
+func (pub *PublicKey) CiphertextLen() int {
+	return pub.Size()
+}
+
+// StrengthBits returns the bit size of the modulus P, which determines
+// the strength of the key.
+func (pub *PublicKey) StrengthBits() int {
+	return pub.P.BitLen()
+}
+
+// MaxMessageSize returns the largest plain text length in bytes that is
+// guaranteed to be smaller than P, and therefore accepted by Encrypt.
+func (pub *PublicKey) MaxMessageSize() int {
+	return (pub.P.BitLen() - 1) / 8
+}
+
+// describeInt returns the bit length and a short hex prefix of x.
+func describeInt(x *big.Int) string {
+	if x == nil {
+		return "<nil>"
+	}
+	h := x.Text(16)
+	if len(h) > 8 {
+		h = h[:8] + "..."
+	}
+	return fmt.Sprintf("%d bits 0x%s", x.BitLen(), h)
+}
+
+// String returns a short description of the public key parameters,
+// consisting of their bit lengths and hex prefixes.
+func (pub *PublicKey) String() string {
+	if pub == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("elgamal.PublicKey{P: %s, G: %s, Y: %s}",
+		describeInt(pub.P), describeInt(pub.G), describeInt(pub.Y))
+}
+
+// GoString returns the same description as String, for the %#v verb.
+func (pub *PublicKey) GoString() string {
+	return pub.String()
+}
+
+// String returns a short description of the public key parameters. The
+// secret exponent X is always redacted, so that a private key never leaks
+// into logs by accident. It has a value receiver, so that keys printed by
+// value or embedded by value in other structs are redacted as well.
+func (priv PrivateKey) String() string {
+	return fmt.Sprintf("elgamal.PrivateKey{P: %s, G: %s, Y: %s, X: [REDACTED]}",
+		describeInt(priv.P), describeInt(priv.G), describeInt(priv.Y))
+}
+
+// GoString returns the same description as String for the %#v verb, which
+// would otherwise print X in full.
+func (priv PrivateKey) GoString() string {
+	return priv.String()
+}
@@ -0,0 +1,156 @@
+package elgamal
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestPrivateKeyStringRedactsX(t *testing.T) {
+	priv := newTestKey(t)
+	x, xd := priv.X.Text(16), priv.X.String()
+	type named struct {
+		Name string
+		Key  PrivateKey
+	}
+	type embedded struct {
+		Name string
+		PrivateKey
+	}
+	for _, v := range []any{
+		priv,
+		*priv,
+		named{"named", *priv},
+		embedded{"embedded", *priv},
+		&named{"named", *priv},
+	} {
+		for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+			s := fmt.Sprintf(format, v)
+			if strings.Contains(s, x) || strings.Contains(s, xd) {
+				t.Errorf("%s of %T contains X: %s", format, v, s)
+			}
+			if !strings.Contains(s, "[REDACTED]") {
+				t.Errorf("%s of %T lacks the redaction: %s", format, v, s)
+			}
+		}
+	}
+	if s := fmt.Sprintf("%#v", &priv.PublicKey); !strings.HasPrefix(s, "elgamal.PublicKey{") {
+		t.Errorf("unexpected %%#v of a public key: %s", s)
+	}
+	var nilKey *PrivateKey
+	if s := fmt.Sprint(nilKey); s != "<nil>" {
+		t.Errorf("nil key printed as %s", s)
+	}
+}
+
+func TestKeyEqual(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+
+	if !pub.Equal(pub.Clone()) || !priv.Equal(priv.Clone()) {
+		t.Error("keys differ from their clones")
+	}
+
+	otherY := pub.Clone()
+	otherY.Y = new(big.Int).Add(pub.Y, one)
+	otherG := pub.Clone()
+	otherG.G = big.NewInt(4)
+	for _, other := range []*PublicKey{otherY, otherG} {
+		if pub.Equal(other) {
+			t.Errorf("equal to a key that differs in one field: %v", other)
+		}
+	}
+	otherX := priv.Clone()
+	otherX.X = new(big.Int).Add(priv.X, one)
+	if priv.Equal(otherX) {
+		t.Error("equal to a key with another secret exponent")
+	}
+
+	if pub.Equal(nil) || pub.Equal((*PublicKey)(nil)) || priv.Equal(nil) || priv.Equal((*PrivateKey)(nil)) {
+		t.Error("equal to nil")
+	}
+	if pub.Equal(priv) {
+		t.Error("public key equal to a private key")
+	}
+}
+
+func TestMessageSizeBoundary(t *testing.T) {
+	keys := []*PrivateKey{newTestKey(t)}
+	for _, g := range []Group{MODP2048, MODP3072} {
+		priv, err := GenerateKeyInGroup(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, priv)
+	}
+	for _, priv := range keys {
+		bits := priv.P.BitLen()
+		if got, want := priv.Size(), (bits+7)/8; got != want {
+			t.Errorf("%d bits: Size() = %d, want %d", bits, got, want)
+		}
+		max := priv.MaxMessageSize()
+		if _, _, err := priv.Encrypt(bytes.Repeat([]byte{0xff}, max)); err != nil {
+			t.Errorf("%d bits: %d bytes rejected: %v", bits, max, err)
+		}
+		if _, _, err := priv.Encrypt(bytes.Repeat([]byte{0xff}, max+1)); err != ErrMessageLarge {
+			t.Errorf("%d bits: %d bytes: got %v, want ErrMessageLarge", bits, max+1, err)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	priv := newTestKey(t)
+	clone := priv.Clone()
+	x, y, g := priv.X.String(), priv.Y.String(), priv.G.String()
+
+	// mutate the original in place; P is shared with the fixture
+	priv.X.Add(priv.X, one)
+	priv.Y.Add(priv.Y, one)
+	priv.G.Add(priv.G, one)
+	if clone.X.String() != x || clone.Y.String() != y || clone.G.String() != g {
+		t.Error("clone shares its parameters with the original")
+	}
+	if clone.P == priv.P || clone.Q == priv.Q {
+		t.Error("clone aliases the modulus of the original")
+	}
+}
+
+func TestNewKeys(t *testing.T) {
+	priv := newTestKey(t)
+	pub, err := NewPublicKey(priv.P, priv.G, priv.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Error("NewPublicKey returned another key")
+	}
+	got, err := NewPrivateKey(priv.P, priv.G, priv.X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) {
+		t.Error("NewPrivateKey returned another key")
+	}
+
+	badP := new(big.Int).Add(priv.P, two)
+	for _, tt := range []struct {
+		name    string
+		p, g, y *big.Int
+	}{
+		{"g = 1", priv.P, one, priv.Y},
+		{"p not a safe prime", badP, priv.G, priv.Y},
+		{"y = 0", priv.P, priv.G, zero},
+		{"y = p", priv.P, priv.G, priv.P},
+	} {
+		if _, err := NewPublicKey(tt.p, tt.g, tt.y); err == nil {
+			t.Errorf("NewPublicKey accepted %s", tt.name)
+		}
+	}
+	for _, x := range []*big.Int{nil, zero, big.NewInt(-1), priv.Order()} {
+		if _, err := NewPrivateKey(priv.P, priv.G, x); err == nil {
+			t.Errorf("NewPrivateKey accepted x = %v", x)
+		}
+	}
+}
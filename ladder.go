@@ -0,0 +1,47 @@
+package elgamal
+
+import "math/big"
+
+// ladderExp returns b^x mod m using the Montgomery ladder over exactly the
+// given number of bits of x, which must be at least x.BitLen(). Every bit
+// costs one multiplication and one squaring, regardless of its value, so
+// the sequence of operations does not depend on the secret exponent x.
+// The underlying big.Int arithmetic is not guaranteed to run in constant
+// time on all operands, so this is a mitigation rather than a guarantee.
+func ladderExp(b, x, m *big.Int, bits int) *big.Int {
+	r := [2]*big.Int{new(big.Int).Set(one), new(big.Int).Mod(b, m)}
+	for i := bits - 1; i >= 0; i-- {
+		bit := x.Bit(i)
+		// r[1-bit] = r[0] * r[1] mod m
+		mulMod(r[1-bit], r[0], r[1], m)
+		// r[bit] = r[bit]^2 mod m
+		mulMod(r[bit], r[bit], r[bit], m)
+	}
+	return r[0]
+}
+
+// expX returns b^x mod p for the secret exponent x. It uses ladderExp
+// over the bit length of p if SecureMode is set, and big.Int.Exp otherwise.
+// The ladder runs over p rather than q since parsed keys only bound x by
+// p - 2, as for expNegX.
+func (priv *PrivateKey) expX(b *big.Int) *big.Int {
+	if priv.SecureMode {
+		return ladderExp(b, priv.X, priv.P, priv.P.BitLen())
+	}
+	return new(big.Int).Exp(b, priv.X, priv.P)
+}
+
+// expNegX returns b^(-x) mod p for b coprime to p, the inverse of expX(b).
+// The order of every such b divides p - 1 = 2q, so b^(-x) = b^(2q - x) mod p,
+// which takes a single exponentiation instead of an exponentiation followed
+// by a modular inversion. Since p is prime, there is no CRT speedup as for
+// RSA; skipping the inversion is all that the factorization of p - 1 offers.
+func (priv *PrivateKey) expNegX(b *big.Int) *big.Int {
+	// e = 2q - x = p - 1 - x
+	e := new(big.Int).Sub(priv.P, one)
+	e.Sub(e, priv.X)
+	if priv.SecureMode {
+		return ladderExp(b, e, priv.P, priv.P.BitLen())
+	}
+	return new(big.Int).Exp(b, e, priv.P)
+}
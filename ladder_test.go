@@ -0,0 +1,100 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestSecureModeLargeExponent(t *testing.T) {
+	priv := newTestKey(t)
+	// x = p - 3 is accepted by parseCipher, but exceeds the bit length of q
+	priv.X = new(big.Int).Sub(priv.P, big.NewInt(3))
+	b := big.NewInt(12345)
+
+	fast, fastNeg := priv.expX(b), priv.expNegX(b)
+	priv.SecureMode = true
+	if got := priv.expX(b); got.Cmp(fast) != 0 {
+		t.Errorf("expX differs in SecureMode: got %v, want %v", got, fast)
+	}
+	if got := priv.expNegX(b); got.Cmp(fastNeg) != 0 {
+		t.Errorf("expNegX differs in SecureMode: got %v, want %v", got, fastNeg)
+	}
+	if got := mulMod(new(big.Int), fast, fastNeg, priv.P); got.Cmp(one) != 0 {
+		t.Errorf("expX * expNegX = %v, want 1", got)
+	}
+}
+
+func TestSecureModeDecrypt(t *testing.T) {
+	priv := newTestKey(t)
+	priv.SecureMode = true
+	c1, c2, err := priv.Encrypt([]byte("ladder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := priv.Decrypt(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m) != "ladder" {
+		t.Errorf("got %q, want %q", m, "ladder")
+	}
+}
+
+func BenchmarkDecryptSecureMode(b *testing.B) {
+	priv := newTestKey(b)
+	c1, c2, err := priv.Encrypt([]byte("ladder"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bb := range []struct {
+		name   string
+		secure bool
+	}{{"Exp", false}, {"Ladder", true}} {
+		b.Run(bb.name, func(b *testing.B) {
+			priv.SecureMode = bb.secure
+			for i := 0; i < b.N; i++ {
+				if _, err := priv.Decrypt(c1, c2); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestExpNegX(t *testing.T) {
+	priv := newTestKey(t)
+	for _, secure := range []bool{false, true} {
+		priv.SecureMode = secure
+		for i := 0; i < 32; i++ {
+			b, err := rand.Int(rand.Reader, priv.P)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Sign() == 0 {
+				continue
+			}
+			want := new(big.Int).Exp(b, priv.X, priv.P)
+			want.ModInverse(want, priv.P)
+			if got := priv.expNegX(b); got.Cmp(want) != 0 {
+				t.Fatalf("SecureMode %v: expNegX(%v) = %v, want %v", secure, b, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkExpNegX(b *testing.B) {
+	priv := newTestKey(b)
+	c := new(big.Int).Sub(priv.P, big.NewInt(12345))
+	b.Run("ExpModInverse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s := new(big.Int).Exp(c, priv.X, priv.P)
+			s.ModInverse(s, priv.P)
+		}
+	})
+	b.Run("expNegX", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			priv.expNegX(c)
+		}
+	})
+}
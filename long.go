@@ -0,0 +1,111 @@
+package elgamal
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// blockMarker is prepended to every block encrypted by EncryptLong,
+// so that leading zero bytes of a block survive decryption.
+const blockMarker = 0x01
+
+// chunkSize returns the number of message bytes carried by each block of
+// EncryptLong. A block consists of blockMarker followed by the chunk, and
+// it is always strictly smaller than P.
+func (pub *PublicKey) chunkSize() int {
+	return pub.MaxMessageSize() - 1
+}
+
+// longHeaderSize is the size of the big-endian message length carried by
+// the header block of EncryptLong.
+const longHeaderSize = 8
+
+// EncryptLong encrypts a plain text of arbitrary length. The message is
+// split into blocks strictly smaller than modulus P of Public key, each of
+// which is encrypted separately. It returns the ordered list of ciphers,
+// starting with a header cipher that holds the length of the message.
+// Every block but the last carries exactly chunkSize bytes, and blockMarker
+// keeps leading zero bytes of each block, so DecryptLong restores the
+// message byte for byte and rejects a list of ciphers that has been
+// truncated or extended.
+func (pub *PublicKey) EncryptLong(message []byte) ([][2][]byte, error) {
+	size := pub.chunkSize()
+	if size < longHeaderSize {
+		return nil, errors.New("elgamal: public key is too small for long messages")
+	}
+
+	ciphertext := make([][2][]byte, 0, 1+(len(message)+size-1)/size)
+	header := make([]byte, 0, 1+longHeaderSize)
+	header = append(header, blockMarker)
+	header = binary.BigEndian.AppendUint64(header, uint64(len(message)))
+	c1, c2, err := pub.Encrypt(header)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext = append(ciphertext, [2][]byte{c1, c2})
+
+	for len(message) > 0 {
+		n := size
+		if len(message) < n {
+			n = len(message)
+		}
+
+		block := make([]byte, 0, n+1)
+		block = append(block, blockMarker)
+		block = append(block, message[:n]...)
+
+		c1, c2, err := pub.Encrypt(block)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext = append(ciphertext, [2][]byte{c1, c2})
+		message = message[n:]
+	}
+	return ciphertext, nil
+}
+
+// DecryptLong decrypts the ordered list of ciphers produced by EncryptLong
+// and returns the concatenation of the recovered blocks. It returns an error
+// if the number of ciphers does not match the length in the header cipher,
+// or if a block other than the last does not carry exactly chunkSize bytes.
+func (priv *PrivateKey) DecryptLong(ciphertext [][2][]byte) ([]byte, error) {
+	size := priv.chunkSize()
+	if size < longHeaderSize || len(ciphertext) == 0 {
+		return nil, errors.New("elgamal: long cipher lacks its header")
+	}
+	header, err := priv.Decrypt(ciphertext[0][0], ciphertext[0][1])
+	if err != nil {
+		return nil, err
+	}
+	if len(header) != 1+longHeaderSize || header[0] != blockMarker {
+		return nil, errors.New("elgamal: invalid header in long cipher")
+	}
+	length := binary.BigEndian.Uint64(header[1:])
+	blocks := ciphertext[1:]
+	n := length / uint64(size)
+	if length%uint64(size) != 0 {
+		n++
+	}
+	if uint64(len(blocks)) != n {
+		return nil, errors.New("elgamal: long cipher has been truncated or extended")
+	}
+
+	message := make([]byte, 0, length)
+	for i := 0; i < len(blocks); i++ {
+		block, err := priv.Decrypt(blocks[i][0], blocks[i][1])
+		if err != nil {
+			return nil, err
+		}
+		if len(block) == 0 || block[0] != blockMarker {
+			return nil, errors.New("elgamal: invalid block in long cipher")
+		}
+		if i < len(blocks)-1 && len(block)-1 != size {
+			return nil, errors.New("elgamal: short block in long cipher")
+		}
+		message = append(message, block[1:]...)
+	}
+	if uint64(len(message)) != length {
+		return nil, errors.New("elgamal: long cipher does not match its length")
+	}
+	return message, nil
+}
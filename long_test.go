@@ -0,0 +1,78 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestLongRoundTrip(t *testing.T) {
+	priv := newTestKey(t)
+	size := priv.chunkSize()
+	inputs := [][]byte{
+		{},
+		{0},
+		make([]byte, size),
+		make([]byte, size+1),
+		append([]byte{0, 0, 0}, bytes.Repeat([]byte{0xab, 0, 0}, size)...),
+	}
+	for _, in := range inputs {
+		ct, err := priv.EncryptLong(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := priv.DecryptLong(ct)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(in), err)
+		}
+		if !bytes.Equal(in, out) {
+			t.Errorf("%d bytes: round trip gave %d bytes", len(in), len(out))
+		}
+	}
+}
+
+func TestLongRejectsTruncatedOrExtended(t *testing.T) {
+	priv := newTestKey(t)
+	size := priv.chunkSize()
+	ct, err := priv.EncryptLong(make([]byte, 2*size+5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra, err := priv.EncryptLong(make([]byte, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][][2][]byte{
+		"empty":         nil,
+		"no header":     ct[1:],
+		"last dropped":  ct[:len(ct)-1],
+		"full block":    append(ct[:len(ct):len(ct)], extra[1]),
+		"header only":   ct[:1],
+		"header as end": append(ct[:len(ct):len(ct)], ct[0]),
+	}
+	for name, c := range tests {
+		if _, err := priv.DecryptLong(c); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestLongMultiKilobyte(t *testing.T) {
+	priv := newTestKey(t)
+	msg := make([]byte, 5000)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := priv.EncryptLong(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := priv.DecryptLong(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Error("multi-kilobyte message changed in the round trip")
+	}
+}
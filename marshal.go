@@ -0,0 +1,201 @@
+package elgamal
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+const (
+	// PublicKeyPEMType is the PEM block type of an Elgamal public key.
+	PublicKeyPEMType = "ELGAMAL PUBLIC KEY"
+	// PrivateKeyPEMType is the PEM block type of an Elgamal private key.
+	PrivateKeyPEMType = "ELGAMAL PRIVATE KEY"
+	// DHParamsPEMType is the PEM block type of Diffie-Hellman parameters,
+	// as written by openssl dhparam.
+	DHParamsPEMType = "DH PARAMETERS"
+)
+
+// publicKeyASN1 is the ASN.1 structure of an Elgamal public key,
+// a SEQUENCE of the INTEGERs P, G, Y and the optional Q.
+type publicKeyASN1 struct {
+	P, G, Y *big.Int
+	Q       *big.Int `asn1:"optional"`
+}
+
+// privateKeyASN1 is the ASN.1 structure of an Elgamal private key,
+// a SEQUENCE of the INTEGERs P, G, Y, X and the optional Q.
+type privateKeyASN1 struct {
+	P, G, Y, X *big.Int
+	Q          *big.Int `asn1:"optional"`
+}
+
+// dhParamsASN1 is the PKCS #3 ASN.1 structure of Diffie-Hellman parameters,
+// a SEQUENCE of the INTEGERs prime, base and the optional privateValueLength.
+type dhParamsASN1 struct {
+	P, G               *big.Int
+	PrivateValueLength int `asn1:"optional"`
+}
+
+var errNonPositiveInt = errors.New("elgamal: key parameters must be positive")
+
+// positive reports whether all passed integers are present and positive.
+// DER INTEGERs may be zero or negative, which no key parameter may be.
+func positive(ints ...*big.Int) bool {
+	for _, x := range ints {
+		if x == nil || x.Sign() <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalPublicKey converts a public key to ASN.1 DER form.
+func MarshalPublicKey(pub *PublicKey) ([]byte, error) {
+	if pub == nil || pub.P == nil || pub.G == nil || pub.Y == nil {
+		return nil, errors.New("elgamal: missing public key parameters")
+	}
+	return asn1.Marshal(publicKeyASN1{
+		P: pub.P,
+		G: pub.G,
+		Y: pub.Y,
+		Q: pub.Order(),
+	})
+}
+
+// MarshalPrivateKey converts a private key to ASN.1 DER form.
+func MarshalPrivateKey(priv *PrivateKey) ([]byte, error) {
+	if priv == nil || priv.P == nil || priv.G == nil || priv.Y == nil || priv.X == nil {
+		return nil, errors.New("elgamal: missing private key parameters")
+	}
+	return asn1.Marshal(privateKeyASN1{
+		P: priv.P,
+		G: priv.G,
+		Y: priv.Y,
+		X: priv.X,
+		Q: priv.Order(),
+	})
+}
+
+// ParsePublicKey parses a public key in ASN.1 DER form. It returns an
+// error if any of the parameters is zero or negative.
+func ParsePublicKey(der []byte) (*PublicKey, error) {
+	var key publicKeyASN1
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("elgamal: trailing data after public key")
+	}
+	if !positive(key.P, key.G, key.Y) || (key.Q != nil && !positive(key.Q)) {
+		return nil, errNonPositiveInt
+	}
+
+	return &PublicKey{
+		G: key.G,
+		P: key.P,
+		Y: key.Y,
+		Q: key.Q,
+	}, nil
+}
+
+// ParsePrivateKey parses a private key in ASN.1 DER form. It returns an
+// error if any of the parameters is zero or negative.
+func ParsePrivateKey(der []byte) (*PrivateKey, error) {
+	var key privateKeyASN1
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("elgamal: trailing data after private key")
+	}
+	if !positive(key.P, key.G, key.Y, key.X) || (key.Q != nil && !positive(key.Q)) {
+		return nil, errNonPositiveInt
+	}
+
+	return &PrivateKey{
+		PublicKey: PublicKey{
+			G: key.G,
+			P: key.P,
+			Y: key.Y,
+			Q: key.Q,
+		},
+		X: key.X,
+	}, nil
+}
+
+// EncodePublicKeyPEM converts a public key to PEM form, wrapping
+// its ASN.1 DER form in an "ELGAMAL PUBLIC KEY" block.
+func EncodePublicKeyPEM(pub *PublicKey) ([]byte, error) {
+	der, err := MarshalPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PublicKeyPEMType, Bytes: der}), nil
+}
+
+// EncodePrivateKeyPEM converts a private key to PEM form, wrapping
+// its ASN.1 DER form in an "ELGAMAL PRIVATE KEY" block.
+func EncodePrivateKeyPEM(priv *PrivateKey) ([]byte, error) {
+	der, err := MarshalPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PrivateKeyPEMType, Bytes: der}), nil
+}
+
+// DecodePublicKeyPEM parses the first PEM block of the given data as a
+// public key. It returns an error if the block is not an "ELGAMAL PUBLIC KEY".
+func DecodePublicKeyPEM(data []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("elgamal: no PEM block found")
+	}
+	if block.Type != PublicKeyPEMType {
+		return nil, errors.New("elgamal: unexpected PEM block type " + block.Type)
+	}
+	return ParsePublicKey(block.Bytes)
+}
+
+// DecodePrivateKeyPEM parses the first PEM block of the given data as a
+// private key. It returns an error if the block is not an "ELGAMAL PRIVATE KEY".
+func DecodePrivateKeyPEM(data []byte) (*PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("elgamal: no PEM block found")
+	}
+	if block.Type != PrivateKeyPEMType {
+		return nil, errors.New("elgamal: unexpected PEM block type " + block.Type)
+	}
+	return ParsePrivateKey(block.Bytes)
+}
+
+// ParseDHParamsPEM parses the first PEM block of the given data as
+// Diffie-Hellman parameters, such as the output of openssl dhparam, so that
+// their prime and base can be reused for Elgamal. It returns an error if the
+// block is not a "DH PARAMETERS", ErrInvalidPrime if the prime is not a safe
+// prime P = 2Q + 1, and ErrGeneratorSubgroup if the base does not lie in the
+// subgroup of prime order Q.
+func ParseDHParamsPEM(data []byte) (*Parameters, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("elgamal: no PEM block found")
+	}
+	if block.Type != DHParamsPEMType {
+		return nil, errors.New("elgamal: unexpected PEM block type " + block.Type)
+	}
+
+	var params dhParamsASN1
+	rest, err := asn1.Unmarshal(block.Bytes, &params)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("elgamal: trailing data after DH parameters")
+	}
+
+	return newParameters(params.P, params.G)
+}
@@ -0,0 +1,188 @@
+package elgamal
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"testing"
+)
+
+func TestDERRoundTrip(t *testing.T) {
+	priv := newTestKey(t)
+
+	der, err := MarshalPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParsePrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) {
+		t.Error("private key changed in the DER round trip")
+	}
+
+	pder, err := MarshalPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(pder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Error("public key changed in the DER round trip")
+	}
+}
+
+func TestParseTruncatedDER(t *testing.T) {
+	priv := newTestKey(t)
+	der, err := MarshalPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(der); i++ {
+		if _, err := ParsePrivateKey(der[:i]); err == nil {
+			t.Fatalf("ParsePrivateKey accepted %d of %d bytes", i, len(der))
+		}
+	}
+	pder, err := MarshalPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(pder); i++ {
+		if _, err := ParsePublicKey(pder[:i]); err == nil {
+			t.Fatalf("ParsePublicKey accepted %d of %d bytes", i, len(pder))
+		}
+	}
+}
+
+func TestPEM(t *testing.T) {
+	priv := newTestKey(t)
+
+	privPEM, err := EncodePrivateKeyPEM(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(privPEM, []byte("-----BEGIN "+PrivateKeyPEMType+"-----\n")) {
+		t.Errorf("unexpected private key header: %.40q", privPEM)
+	}
+	got, err := DecodePrivateKeyPEM(privPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) {
+		t.Error("private key changed in the PEM round trip")
+	}
+
+	pubPEM, err := EncodePublicKeyPEM(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pubPEM, []byte("-----BEGIN "+PublicKeyPEMType+"-----\n")) {
+		t.Errorf("unexpected public key header: %.40q", pubPEM)
+	}
+	pub, err := DecodePublicKeyPEM(pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Error("public key changed in the PEM round trip")
+	}
+
+	if _, err := DecodePublicKeyPEM(privPEM); err == nil {
+		t.Error("DecodePublicKeyPEM accepted a private key block")
+	}
+	if _, err := DecodePrivateKeyPEM(pubPEM); err == nil {
+		t.Error("DecodePrivateKeyPEM accepted a public key block")
+	}
+	if _, err := DecodePublicKeyPEM([]byte("not pem")); err == nil {
+		t.Error("DecodePublicKeyPEM accepted data without a PEM block")
+	}
+}
+
+func TestParseDHParamsPEM(t *testing.T) {
+	// generated with openssl dhparam 1024
+	data, err := os.ReadFile("testdata/dhparam.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := ParseDHParamsPEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testParams()
+	if params.P.Cmp(want.P) != 0 || params.G.Cmp(want.G) != 0 || params.Q.Cmp(want.Q) != 0 {
+		t.Errorf("got <p,q,g> = <%v,%v,%v>", params.P, params.Q, params.G)
+	}
+
+	pubPEM, err := EncodePublicKeyPEM(&newTestKey(t).PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseDHParamsPEM(pubPEM); err == nil {
+		t.Error("accepted a public key block")
+	}
+}
+
+func TestParseNonPositiveDER(t *testing.T) {
+	for _, p := range []*big.Int{big.NewInt(0), big.NewInt(-23)} {
+		der, err := asn1.Marshal(publicKeyASN1{P: p, G: big.NewInt(2), Y: big.NewInt(3)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParsePublicKey(der); err != errNonPositiveInt {
+			t.Errorf("public key with P = %v: got %v, want errNonPositiveInt", p, err)
+		}
+		der, err = asn1.Marshal(privateKeyASN1{P: big.NewInt(23), G: big.NewInt(2), Y: big.NewInt(3), X: p})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParsePrivateKey(der); err != errNonPositiveInt {
+			t.Errorf("private key with X = %v: got %v, want errNonPositiveInt", p, err)
+		}
+	}
+	der, err := asn1.Marshal(publicKeyASN1{P: big.NewInt(23), G: big.NewInt(2), Y: big.NewInt(3), Q: big.NewInt(-11)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePublicKey(der); err != errNonPositiveInt {
+		t.Errorf("public key with Q = -11: got %v, want errNonPositiveInt", err)
+	}
+}
+
+func FuzzParsePublicKey(f *testing.F) {
+	priv := newTestKey(f)
+	der, err := MarshalPublicKey(&priv.PublicKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+	bin, err := priv.PublicKey.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(der)
+	f.Add(bin)
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 1})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if pub, err := ParsePublicKey(data); err == nil {
+			if pub.P.Sign() <= 0 || pub.G.Sign() <= 0 || pub.Y.Sign() <= 0 {
+				t.Fatal("parsed a non-positive parameter")
+			}
+			pub.Validate()
+		}
+		var pub PublicKey
+		if err := pub.UnmarshalBinary(data); err == nil {
+			if pub.P.Sign() <= 0 || pub.G.Sign() <= 0 || pub.Y.Sign() <= 0 {
+				t.Fatal("unmarshaled a non-positive parameter")
+			}
+			pub.Validate()
+		}
+		ParsePrivateKey(data)
+		new(PrivateKey).UnmarshalBinary(data)
+		DecodePublicKeyPEM(data)
+		ParseDHParamsPEM(data)
+	})
+}
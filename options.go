@@ -0,0 +1,80 @@
+package elgamal
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+)
+
+// maxRerolls bounds the number of safe primes that GenerateKeyWithOptions
+// discards because they fail the structural checks.
+const maxRerolls = 64
+
+// GenerateKeyOptions configures GenerateKeyWithOptions. Safe primes already
+// guarantee that p-1 has no factors other than 2 and q; the options add
+// defense-in-depth checks against primes with other special structure.
+type GenerateKeyOptions struct {
+	// Bits and Probability are the bit size and probability of GenerateKey.
+	Bits, Probability int
+
+	// MinPowerOfTwoDistance, if positive, rejects q if the distance between
+	// q and the nearest power of two has fewer than this many bits.
+	MinPowerOfTwoDistance int
+
+	// PrimeCheck, if not nil, rejects p = 2q + 1 if it returns false.
+	PrimeCheck func(p, q *big.Int) bool
+
+	// Primality, if not nil, rejects p = 2q + 1 unless both p and q pass
+	// it, such as MillerRabinLucas for a stronger primality guarantee.
+	Primality PrimalityTest
+}
+
+// GenerateKeyWithOptions is like GenerateKey, but it re-rolls the safe prime
+// P until it passes the structural checks configured in opts. It returns
+// ErrGenerationFailed if no prime passes them within a bounded number of
+// attempts. A nil opts is treated like the zero GenerateKeyOptions.
+func GenerateKeyWithOptions(opts *GenerateKeyOptions) (*PrivateKey, error) {
+	if opts == nil {
+		opts = new(GenerateKeyOptions)
+	}
+	for i := 0; i < maxRerolls; i++ {
+		// p is prime number
+		// q is prime group order
+		// g is cyclic group generator Zp
+		p, q, g, err := genContext(context.Background(), rand.Reader, opts.Bits, opts.Probability)
+		if err != nil {
+			return nil, err
+		}
+		if opts.accept(p, q) {
+			return newPrivateKey(rand.Reader, p, q, g)
+		}
+	}
+	return nil, ErrGenerationFailed
+}
+
+// accept reports whether p = 2q + 1 passes the checks of opts.
+func (opts *GenerateKeyOptions) accept(p, q *big.Int) bool {
+	if opts.MinPowerOfTwoDistance > 0 &&
+		powerOfTwoDistance(q).BitLen() < opts.MinPowerOfTwoDistance {
+		return false
+	}
+	if opts.PrimeCheck != nil && !opts.PrimeCheck(p, q) {
+		return false
+	}
+	if opts.Primality != nil && !(opts.Primality(q) && opts.Primality(p)) {
+		return false
+	}
+	return true
+}
+
+// powerOfTwoDistance returns the distance between x > 0 and the nearest
+// power of two, which is either 2^(n-1) or 2^n for an n-bit x.
+func powerOfTwoDistance(x *big.Int) *big.Int {
+	n := uint(x.BitLen())
+	below := new(big.Int).Sub(x, new(big.Int).Lsh(one, n-1))
+	above := new(big.Int).Sub(new(big.Int).Lsh(one, n), x)
+	if below.Cmp(above) < 0 {
+		return below
+	}
+	return above
+}
@@ -0,0 +1,50 @@
+package elgamal
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestGenerateKeyWithOptionsRerolls(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	var rejected *big.Int
+	priv, err := GenerateKeyWithOptions(&GenerateKeyOptions{
+		Bits:        512,
+		Probability: 20,
+		PrimeCheck: func(p, q *big.Int) bool {
+			if rejected == nil {
+				rejected = p
+				return false
+			}
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected == nil || priv.P.Cmp(rejected) == 0 {
+		t.Error("rejected prime was not re-rolled")
+	}
+	if err := priv.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerateKeyWithNilOptions(t *testing.T) {
+	// nil options are the zero options, whose zero bit size is insecure
+	if _, err := GenerateKeyWithOptions(nil); err != ErrInsecureParameters {
+		t.Errorf("got %v, want ErrInsecureParameters", err)
+	}
+}
+
+func TestPowerOfTwoDistance(t *testing.T) {
+	for _, tt := range []struct{ x, want int64 }{
+		{17, 1}, {30, 2}, {16, 0}, {24, 8},
+	} {
+		if got := powerOfTwoDistance(big.NewInt(tt.x)); got.Int64() != tt.want {
+			t.Errorf("powerOfTwoDistance(%d) = %v, want %d", tt.x, got, tt.want)
+		}
+	}
+}
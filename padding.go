@@ -0,0 +1,54 @@
+package elgamal
+
+import "errors"
+
+var ErrInvalidPadding = errors.New("elgamal: invalid message padding")
+
+// MaxPaddedMessageSize returns the largest message length in bytes that
+// EncryptPadded accepts, which is (P.BitLen()-1)/8 - 1.
+func (pub *PublicKey) MaxPaddedMessageSize() int {
+	return pub.MaxMessageSize() - 1
+}
+
+// EncryptPadded encrypts a plain text like Encrypt, but it first pads the
+// message to the fixed width of MaxMessageSize bytes. The message is
+// followed by the byte 0x80 and as many zero bytes as needed, so that
+// DecryptPadded returns a message of exactly the original length, leading
+// zero bytes included. It returns ErrMessageLarge if the message is longer
+// than MaxPaddedMessageSize.
+func (pub *PublicKey) EncryptPadded(message []byte) ([]byte, []byte, error) {
+	size := pub.MaxMessageSize()
+	if len(message) > size-1 {
+		return nil, nil, ErrMessageLarge
+	}
+
+	block := make([]byte, size)
+	copy(block, message)
+	block[len(message)] = 0x80
+	return pub.Encrypt(block)
+}
+
+// DecryptPadded decrypts a cipher produced by EncryptPadded and removes
+// the padding. It returns ErrInvalidPadding if the padding is malformed.
+func (priv *PrivateKey) DecryptPadded(cipher1, cipher2 []byte) ([]byte, error) {
+	m, err := priv.DecryptBig(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+
+	size := priv.MaxMessageSize()
+	if (m.BitLen()+7)/8 > size {
+		return nil, ErrInvalidPadding
+	}
+	block := m.FillBytes(make([]byte, size))
+
+	// strip trailing zero bytes and the 0x80 marker
+	i := len(block) - 1
+	for i >= 0 && block[i] == 0 {
+		i--
+	}
+	if i < 0 || block[i] != 0x80 {
+		return nil, ErrInvalidPadding
+	}
+	return block[:i], nil
+}
@@ -0,0 +1,35 @@
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPaddedLeadingZeros(t *testing.T) {
+	priv := newTestKey(t)
+	max := priv.MaxPaddedMessageSize()
+	inputs := [][]byte{
+		{},
+		{0},
+		{0, 0, 0},
+		{0, 0, 1},
+		{0, 0x80, 0},
+		make([]byte, max),
+	}
+	for _, msg := range inputs {
+		c1, c2, err := priv.EncryptPadded(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := priv.DecryptPadded(c1, c2)
+		if err != nil {
+			t.Fatalf("%x: %v", msg, err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("got %x, want %x", got, msg)
+		}
+	}
+	if _, _, err := priv.EncryptPadded(make([]byte, max+1)); err != ErrMessageLarge {
+		t.Errorf("oversized message: got %v, want ErrMessageLarge", err)
+	}
+}
@@ -0,0 +1,103 @@
+package elgamal
+
+import (
+	"crypto/dsa"
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// Parameters represents the group parameters <p,q,g> shared by many keys.
+// P is a safe prime P = 2Q + 1 and G generates the subgroup of prime order Q.
+type Parameters struct {
+	P, Q, G *big.Int
+}
+
+// GenerateParameters generates group parameters according to given bit size
+// and probability, as in GenerateKey. Generating the parameters is the
+// expensive part of key generation, so a trusted authority may generate them
+// once and let many parties derive their keys with Parameters.GenerateKey.
+func GenerateParameters(bitsize, probability int) (*Parameters, error) {
+	p, q, g, err := GeneratePQZp(bitsize, probability)
+	if err != nil {
+		return nil, err
+	}
+	return &Parameters{P: p, Q: q, G: g}, nil
+}
+
+// newParameters returns the group parameters with the modulus p and the
+// generator g after checking that p is a safe prime and that g generates
+// the subgroup of prime order q = (p-1)/2.
+func newParameters(p, g *big.Int) (*Parameters, error) {
+	if !IsSafePrime(p, validationRounds) {
+		return nil, ErrInvalidPrime
+	}
+	// q = (p-1)/2
+	q := new(big.Int).Rsh(p, 1)
+	if g == nil || g.Cmp(one) <= 0 || g.Cmp(p) >= 0 {
+		return nil, ErrInvalidGenerator
+	}
+	if new(big.Int).Exp(g, q, p).Cmp(one) != 0 {
+		return nil, ErrGeneratorSubgroup
+	}
+	return &Parameters{P: p, Q: q, G: g}, nil
+}
+
+// ToDSAParameters returns the group parameters in the form of crypto/dsa.
+func (params *Parameters) ToDSAParameters() dsa.Parameters {
+	return dsa.Parameters{P: params.P, Q: params.Q, G: params.G}
+}
+
+// ToDSAParameters returns the group parameters of the key in the form of
+// crypto/dsa.
+func (pub *PublicKey) ToDSAParameters() dsa.Parameters {
+	return dsa.Parameters{P: pub.P, Q: pub.Order(), G: pub.G}
+}
+
+// FromDSAParameters returns the group parameters held by dp after checking
+// them like ParseDHParamsPEM. P must be a safe prime with Q = (P-1)/2, so the
+// groups produced by dsa.GenerateParameters, whose Q is much smaller, are
+// rejected with ErrInvalidPrime.
+func FromDSAParameters(dp dsa.Parameters) (*Parameters, error) {
+	if dp.P == nil || dp.Q == nil || dp.G == nil {
+		return nil, errors.New("elgamal: missing DSA parameters")
+	}
+	params, err := newParameters(dp.P, dp.G)
+	if err != nil {
+		return nil, err
+	}
+	if params.Q.Cmp(dp.Q) != 0 {
+		return nil, ErrInvalidPrime
+	}
+	return params, nil
+}
+
+// GenerateKey generates elgamal private key in the group of params.
+// Only the secret exponent x is chosen at random.
+func (params *Parameters) GenerateKey() (*PrivateKey, error) {
+	return newPrivateKey(rand.Reader, params.P, params.Q, params.G)
+}
+
+// KeyFromExponent returns the private key with the passed secret exponent x
+// in the group of params, computing y = g^x mod p once. Unlike NewPrivateKey,
+// it trusts params like GenerateKey and skips the costly validation of the
+// group, so that callers may cheaply derive keys from derived or ratcheted
+// exponents. The secret exponent must lie in {1...(q-1)}.
+func KeyFromExponent(params *Parameters, x *big.Int) (*PrivateKey, error) {
+	if x == nil || x.Sign() <= 0 || x.Cmp(params.Q) >= 0 {
+		return nil, errors.New("elgamal: secret exponent is out of range")
+	}
+
+	// y = g^x mod p
+	y := new(big.Int).Exp(params.G, x, params.P)
+
+	return &PrivateKey{
+		PublicKey: PublicKey{
+			G: params.G,
+			P: params.P,
+			Y: y,
+			Q: params.Q,
+		},
+		X: new(big.Int).Set(x),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package elgamal
+
+import (
+	"crypto/dsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestParametersKeysInteroperate(t *testing.T) {
+	params := testParams()
+	keys := make([]*PrivateKey, 5)
+	for i := range keys {
+		priv, err := params.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.P.Cmp(params.P) != 0 || priv.G.Cmp(params.G) != 0 {
+			t.Fatal("key left the group of its parameters")
+		}
+		keys[i] = priv
+	}
+	for i, priv := range keys {
+		c1, c2, err := priv.Encrypt(big.NewInt(6).Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		d1, d2, err := priv.Encrypt(big.NewInt(7).Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// any key of the group combines the ciphers of another key
+		other := keys[(i+1)%len(keys)]
+		e1, e2, err := other.HomomorphicEncTwo(c1, c2, d1, d2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := priv.DecryptBig(e1, e2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Int64() != 42 {
+			t.Errorf("key %d: product decrypted to %v, want 42", i, m)
+		}
+	}
+}
+
+func TestGenerateParameters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	params, err := GenerateParameters(512, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := params.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.PublicKey.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyFromExponent(t *testing.T) {
+	params := testParams()
+	x := big.NewInt(12345)
+	priv, err := KeyFromExponent(params, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Exp(params.G, x, params.P); priv.Y.Cmp(want) != 0 {
+		t.Error("y != g^x")
+	}
+	if err := priv.Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, x := range []*big.Int{nil, big.NewInt(0), big.NewInt(-1), params.Q} {
+		if _, err := KeyFromExponent(params, x); err == nil {
+			t.Errorf("accepted x = %v", x)
+		}
+	}
+}
+
+func TestDSAParameters(t *testing.T) {
+	params := testParams()
+	dp := params.ToDSAParameters()
+	if dp.P.Cmp(params.P) != 0 || dp.Q.Cmp(params.Q) != 0 || dp.G.Cmp(params.G) != 0 {
+		t.Error("ToDSAParameters changed the group")
+	}
+	got, err := FromDSAParameters(dp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.P.Cmp(params.P) != 0 || got.Q.Cmp(params.Q) != 0 || got.G.Cmp(params.G) != 0 {
+		t.Error("group changed in the round trip")
+	}
+
+	priv := newTestKey(t)
+	if priv.PublicKey.ToDSAParameters().Q.Cmp(params.Q) != 0 {
+		t.Error("ToDSAParameters of a key has another Q")
+	}
+
+	if _, err := FromDSAParameters(dsa.Parameters{}); err == nil {
+		t.Error("accepted empty parameters")
+	}
+	dp.Q = big.NewInt(11)
+	if _, err := FromDSAParameters(dp); err != ErrInvalidPrime {
+		t.Errorf("Q != (P-1)/2: got %v, want ErrInvalidPrime", err)
+	}
+}
+
+func TestFromGeneratedDSAParameters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates DSA parameters")
+	}
+	var dp dsa.Parameters
+	if err := dsa.GenerateParameters(&dp, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromDSAParameters(dp); err != ErrInvalidPrime {
+		t.Errorf("got %v, want ErrInvalidPrime", err)
+	}
+}
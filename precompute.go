@@ -0,0 +1,91 @@
+package elgamal
+
+import "math/big"
+
+// precomputeWindow is the window width in bits of the fixed-base tables.
+const precomputeWindow = 4
+
+// fixedBaseTable holds the powers b^(j * 2^(w*i)) mod p of a fixed base b
+// for every window position i and every window value j, so that b^k mod p
+// is computed with one multiplication per window of k.
+type fixedBaseTable struct {
+	p      *big.Int
+	powers [][]*big.Int
+}
+
+// precomputed holds the fixed-base tables of the generator G and the
+// public value Y of a public key.
+type precomputed struct {
+	g, y *fixedBaseTable
+}
+
+// newFixedBaseTable builds the table of base b modulo p for
+// exponents of at most the given number of bits.
+func newFixedBaseTable(b, p *big.Int, bits int) *fixedBaseTable {
+	windows := (bits + precomputeWindow - 1) / precomputeWindow
+	t := &fixedBaseTable{
+		p:      p,
+		powers: make([][]*big.Int, windows),
+	}
+
+	// base = b^(2^(w*i)) mod p
+	base := new(big.Int).Set(b)
+	for i := 0; i < windows; i++ {
+		row := make([]*big.Int, 1<<precomputeWindow)
+		row[0] = one
+		for j := 1; j < len(row); j++ {
+			row[j] = new(big.Int).Mod(new(big.Int).Mul(row[j-1], base), p)
+		}
+		t.powers[i] = row
+		base = new(big.Int).Mod(new(big.Int).Mul(row[len(row)-1], base), p)
+	}
+	return t
+}
+
+// exp returns b^k mod p. It falls back to big.Int.Exp if k has
+// more bits than the table covers.
+func (t *fixedBaseTable) exp(b, k *big.Int) *big.Int {
+	if k.BitLen() > len(t.powers)*precomputeWindow {
+		return new(big.Int).Exp(b, k, t.p)
+	}
+
+	r := new(big.Int).Set(one)
+	for i := 0; i < len(t.powers); i++ {
+		var j uint
+		for bit := 0; bit < precomputeWindow; bit++ {
+			j |= k.Bit(i*precomputeWindow+bit) << bit
+		}
+		if j != 0 {
+			mulMod(r, r, t.powers[i][j], t.p)
+		}
+	}
+	return r
+}
+
+// Precompute builds and caches fixed-base exponentiation tables for G and
+// Y, which speeds up subsequent calls to Encrypt under the same public key.
+// It should be called before the key is shared between goroutines, and it
+// must be called again if any of the parameters is changed afterwards.
+func (pub *PublicKey) Precompute() {
+	bits := pub.Order().BitLen()
+	pub.precomputed = &precomputed{
+		g: newFixedBaseTable(pub.G, pub.P, bits),
+		y: newFixedBaseTable(pub.Y, pub.P, bits),
+	}
+}
+
+// expG returns g^k mod p, using the precomputed table when present.
+func (pub *PublicKey) expG(k *big.Int) *big.Int {
+	if pub.precomputed != nil {
+		return pub.precomputed.g.exp(pub.G, k)
+	}
+	return new(big.Int).Exp(pub.G, k, pub.P)
+}
+
+// expY returns y^k mod p, using the precomputed table when present.
+func (pub *PublicKey) expY(k *big.Int) *big.Int {
+	if pub.precomputed != nil {
+		return pub.precomputed.y.exp(pub.Y, k)
+	}
+	return new(big.Int).Exp(pub.Y, k, pub.P)
+}
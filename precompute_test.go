@@ -0,0 +1,62 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPrecompute(t *testing.T) {
+	priv := newTestKey(t)
+	pub := priv.PublicKey.Clone()
+	pub.Precompute()
+	for i := 0; i < 100; i++ {
+		k, err := randExponent(rand.Reader, pub.Order())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.expG(k).Cmp(new(big.Int).Exp(pub.G, k, pub.P)) != 0 {
+			t.Fatalf("precomputed g^%v differs", k)
+		}
+		if pub.expY(k).Cmp(new(big.Int).Exp(pub.Y, k, pub.P)) != 0 {
+			t.Fatalf("precomputed y^%v differs", k)
+		}
+	}
+
+	k := big.NewInt(424242)
+	c1, c2, err := pub.EncryptWithK([]byte("table"), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1, d2, err := priv.EncryptWithK([]byte("table"), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c1, d1) || !bytes.Equal(c2, d2) {
+		t.Error("precomputed cipher differs")
+	}
+}
+
+func BenchmarkEncryptPrecompute(b *testing.B) {
+	priv, err := GenerateKeyInGroup(MODP2048)
+	if err != nil {
+		b.Fatal(err)
+	}
+	msg := []byte("benchmark")
+	for _, pre := range []bool{false, true} {
+		pub := priv.PublicKey.Clone()
+		name := "plain"
+		if pre {
+			pub.Precompute()
+			name = "precomputed"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := pub.Encrypt(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
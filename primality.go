@@ -0,0 +1,100 @@
+package elgamal
+
+import "math/big"
+
+// PrimalityTest reports whether n is prime with high probability.
+type PrimalityTest func(n *big.Int) bool
+
+// MillerRabinLucas returns a PrimalityTest that requires n to pass both
+// rounds Miller-Rabin tests with random bases and a strong Lucas probable
+// prime test with the parameters of Selfridge, which together form the
+// Baillie-PSW test. Note that ProbablyPrime of math/big already applies a
+// Lucas test with different parameters; this one is computed independently
+// of it, so that a prime must pass two distinct Lucas tests.
+func MillerRabinLucas(rounds int) PrimalityTest {
+	return func(n *big.Int) bool {
+		return n.ProbablyPrime(rounds) && lucasProbablyPrime(n)
+	}
+}
+
+// lucasProbablyPrime performs the strong Lucas probable prime test with the
+// parameters P = 1 and Q = (1 - D)/4 chosen by method A of Selfridge, where
+// D is the first of 5, -7, 9, -11, ... with Jacobi symbol (D/n) = -1.
+func lucasProbablyPrime(n *big.Int) bool {
+	if n.Cmp(two) <= 0 || n.Bit(0) == 0 {
+		return n.Cmp(two) == 0
+	}
+	// a perfect square has no D with (D/n) = -1
+	if r := new(big.Int).Sqrt(n); r.Mul(r, r).Cmp(n) == 0 {
+		return false
+	}
+
+	// D = 5, -7, 9, -11, ...
+	d := big.NewInt(5)
+	for {
+		j := big.Jacobi(d, n)
+		if j == -1 {
+			break
+		}
+		if j == 0 {
+			// D shares a factor with n, so n is prime only if |D| = n
+			return new(big.Int).Abs(d).Cmp(n) == 0
+		}
+		if d.Sign() > 0 {
+			d.Add(d, two).Neg(d)
+		} else {
+			d.Neg(d).Add(d, two)
+		}
+	}
+
+	// Q = (1 - D)/4 mod n
+	q := new(big.Int).Sub(one, d)
+	q.Rsh(q, 2).Mod(q, n)
+	d.Mod(d, n)
+
+	// n + 1 = k * 2^s with k odd
+	k := new(big.Int).Add(n, one)
+	s := k.TrailingZeroBits()
+	k.Rsh(k, s)
+
+	// half returns x/2 mod n for x in {0...(2n-1)}
+	half := func(x *big.Int) *big.Int {
+		x.Mod(x, n)
+		if x.Bit(0) == 1 {
+			x.Add(x, n)
+		}
+		return x.Rsh(x, 1)
+	}
+
+	// compute U_k, V_k and Q^k mod n from the most significant bit of k,
+	// starting with U_0 = 0, V_0 = 2 and Q^0 = 1
+	u, v, qk := new(big.Int), big.NewInt(2), big.NewInt(1)
+	t := new(big.Int)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		// U_2j = U_j * V_j, V_2j = V_j^2 - 2Q^j, Q^2j = (Q^j)^2
+		mulMod(u, u, v, n)
+		v.Mul(v, v).Sub(v, t.Lsh(qk, 1)).Mod(v, n)
+		mulMod(qk, qk, qk, n)
+		if k.Bit(i) == 1 {
+			// U_(j+1) = (U_j + V_j)/2, V_(j+1) = (D * U_j + V_j)/2
+			t.Mul(d, u).Add(t, v)
+			u = half(u.Add(u, v))
+			v = half(new(big.Int).Set(t))
+			mulMod(qk, qk, q, n)
+		}
+	}
+
+	// n is a strong Lucas probable prime if U_k = 0 or V_(k*2^r) = 0
+	// for some r in {0...(s-1)}
+	if u.Sign() == 0 {
+		return true
+	}
+	for r := uint(0); r < s; r++ {
+		if v.Sign() == 0 {
+			return true
+		}
+		v.Mul(v, v).Sub(v, t.Lsh(qk, 1)).Mod(v, n)
+		mulMod(qk, qk, qk, n)
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package elgamal
+
+import (
+	"math/big"
+	"testing"
+)
+
+// strongLucasPseudoprimes are the composites below 100000 that pass the
+// strong Lucas test with the parameters of Selfridge (OEIS A217255).
+var strongLucasPseudoprimes = map[int64]bool{
+	5459: true, 5777: true, 10877: true, 16109: true, 18971: true, 22499: true,
+	24569: true, 25199: true, 40309: true, 58519: true, 75077: true, 97439: true,
+}
+
+func TestLucasProbablyPrime(t *testing.T) {
+	for i := int64(0); i < 100000; i++ {
+		n := big.NewInt(i)
+		want := n.ProbablyPrime(20) || strongLucasPseudoprimes[i]
+		if got := lucasProbablyPrime(n); got != want {
+			t.Errorf("lucasProbablyPrime(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestMillerRabinLucas(t *testing.T) {
+	isPrime := MillerRabinLucas(20)
+	if !isPrime(testP) || !isPrime(testParams().Q) {
+		t.Error("rejected the test safe prime")
+	}
+	for n := range strongLucasPseudoprimes {
+		if isPrime(big.NewInt(n)) {
+			t.Errorf("accepted the pseudoprime %d", n)
+		}
+	}
+}
+
+func TestGenerateKeyWithPrimality(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	priv, err := GenerateKeyWithOptions(&GenerateKeyOptions{
+		Bits:        512,
+		Probability: 20,
+		Primality:   MillerRabinLucas(20),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lucasProbablyPrime(priv.P) || !lucasProbablyPrime(priv.Order()) {
+		t.Error("generated primes fail the Lucas test")
+	}
+}
@@ -0,0 +1,448 @@
+package elgamal
+
+import (
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha512" // register SHA-512 for crypto.Hash
+	"errors"
+	"math/big"
+)
+
+// KnowledgeProof is a non-interactive Schnorr proof of knowledge of the
+// secret exponent x of a public key y = g^x mod p.
+type KnowledgeProof struct {
+	Commitment *big.Int    // t = g^v mod p
+	Response   *big.Int    // z = v + c*x mod q
+	Hash       crypto.Hash // hash of the challenge, SHA-256 if zero
+}
+
+// proofHash returns the hash function of the challenge of a proof,
+// which defaults to SHA-256 if h is zero.
+func proofHash(h crypto.Hash) crypto.Hash {
+	if h == 0 {
+		return crypto.SHA256
+	}
+	return h
+}
+
+// challenge computes the Fiat-Shamir challenge c = H(label || values) mod q,
+// where H is the hash function h and every value is written as a 4-byte
+// big-endian length followed by its big-endian bytes.
+func challenge(h crypto.Hash, q *big.Int, label string, values ...*big.Int) *big.Int {
+	buf := []byte(label)
+	for _, v := range values {
+		buf = appendInt(buf, v)
+	}
+	hasher := h.New()
+	hasher.Write(buf)
+	digest := hasher.Sum(nil)
+	return new(big.Int).Mod(new(big.Int).SetBytes(digest), q)
+}
+
+// ProveKnowledge proves that the holder of priv knows the secret exponent x
+// without revealing it. A random v is chosen, the commitment is t = g^v mod p,
+// the challenge is c = SHA-256(p, g, y, t) mod q and the response is
+// z = v + c*x mod q.
+func ProveKnowledge(priv *PrivateKey) (*KnowledgeProof, error) {
+	return ProveKnowledgeHash(priv, crypto.SHA256)
+}
+
+// ProveKnowledgeHash is like ProveKnowledge, but it computes the challenge
+// with the hash function h, which is recorded in the proof.
+func ProveKnowledgeHash(priv *PrivateKey, h crypto.Hash) (*KnowledgeProof, error) {
+	if !h.Available() {
+		return nil, errors.New("elgamal: hash function is not available")
+	}
+	q := priv.Order()
+
+	// choose random integer v from {1...(q-1)}
+	v, err := randExponent(rand.Reader, q)
+	if err != nil {
+		return nil, err
+	}
+	// t = g^v mod p
+	t := new(big.Int).Exp(priv.G, v, priv.P)
+	// c = H(p, g, y, t) mod q
+	c := challenge(h, q, "elgamal-knowledge", priv.P, priv.G, priv.Y, t)
+	// z = v + c*x mod q
+	z := new(big.Int).Mod(
+		new(big.Int).Add(v, new(big.Int).Mul(c, priv.X)),
+		q,
+	)
+	return &KnowledgeProof{Commitment: t, Response: z, Hash: h}, nil
+}
+
+// VerifyKnowledge verifies a proof produced by ProveKnowledge. It reports
+// whether g^z = t * y^c mod p holds for the recomputed challenge c, which
+// is computed with the hash function recorded in the proof.
+func VerifyKnowledge(pub *PublicKey, proof *KnowledgeProof) bool {
+	if proof == nil || proof.Commitment == nil || proof.Response == nil {
+		return false
+	}
+	h := proofHash(proof.Hash)
+	if !h.Available() {
+		return false
+	}
+	q := pub.Order()
+	t, z := proof.Commitment, proof.Response
+	if t.Sign() <= 0 || t.Cmp(pub.P) >= 0 || z.Sign() < 0 || z.Cmp(q) >= 0 {
+		return false
+	}
+
+	// c = H(p, g, y, t) mod q
+	c := challenge(h, q, "elgamal-knowledge", pub.P, pub.G, pub.Y, t)
+	// g^z mod p
+	left := new(big.Int).Exp(pub.G, z, pub.P)
+	// t * y^c mod p
+	right := new(big.Int).Mod(
+		new(big.Int).Mul(t, new(big.Int).Exp(pub.Y, c, pub.P)),
+		pub.P,
+	)
+	return left.Cmp(right) == 0
+}
+
+// DecryptionProof is a non-interactive Chaum-Pedersen proof that a plain
+// text is the correct decryption of a cipher, i.e. that the shared secret
+// s = c2 * m^(-1) mod p satisfies log_g(y) = log_c1(s).
+type DecryptionProof struct {
+	A        *big.Int    // a = g^v mod p
+	B        *big.Int    // b = c1^v mod p
+	Response *big.Int    // z = v + c*x mod q
+	Hash     crypto.Hash // hash of the challenge, SHA-256 if zero
+}
+
+// ProveDecryption decrypts the passed cipher and proves that the returned
+// plain text is its correct decryption under priv, without revealing x.
+// The challenge is c = SHA-256(p, g, y, c1, c2, s, a, b) mod q.
+func ProveDecryption(priv *PrivateKey, c1, c2 []byte) (plaintext []byte, proof *DecryptionProof, err error) {
+	return ProveDecryptionHash(priv, c1, c2, crypto.SHA256)
+}
+
+// ProveDecryptionHash is like ProveDecryption, but it computes the challenge
+// with the hash function h, which is recorded in the proof.
+func ProveDecryptionHash(priv *PrivateKey, c1, c2 []byte, h crypto.Hash) (plaintext []byte, proof *DecryptionProof, err error) {
+	if !h.Available() {
+		return nil, nil, errors.New("elgamal: hash function is not available")
+	}
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(priv.P) >= 0 || cipher2.Cmp(priv.P) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+
+	plaintext, err = priv.Decrypt(c1, c2)
+	if err != nil {
+		return nil, nil, err
+	}
+	q := priv.Order()
+	// s = c1^x mod p
+	s := priv.expX(cipher1)
+
+	// choose random integer v from {1...(q-1)}
+	v, err := randExponent(rand.Reader, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	// a = g^v mod p
+	a := new(big.Int).Exp(priv.G, v, priv.P)
+	// b = c1^v mod p
+	b := new(big.Int).Exp(cipher1, v, priv.P)
+	// c = H(p, g, y, c1, c2, s, a, b) mod q
+	c := challenge(h, q, "elgamal-decryption", priv.P, priv.G, priv.Y, cipher1, cipher2, s, a, b)
+	// z = v + c*x mod q
+	z := new(big.Int).Mod(
+		new(big.Int).Add(v, new(big.Int).Mul(c, priv.X)),
+		q,
+	)
+	return plaintext, &DecryptionProof{A: a, B: b, Response: z, Hash: h}, nil
+}
+
+// VerifyDecryption verifies a proof produced by ProveDecryption. It reports
+// whether plaintext is the correct decryption of the cipher (c1, c2) under
+// pub, by checking g^z = a * y^c mod p and c1^z = b * s^c mod p with the
+// shared secret s = c2 * m^(-1) mod p. The challenge is computed with the
+// hash function recorded in the proof. It also requires c1, s, a and b to
+// lie in the subgroup of order q, since a claimed plain text p - m would
+// otherwise pass with the shared secret -s for every even challenge.
+func VerifyDecryption(pub *PublicKey, c1, c2, plaintext []byte, proof *DecryptionProof) bool {
+	if proof == nil || proof.A == nil || proof.B == nil || proof.Response == nil {
+		return false
+	}
+	h := proofHash(proof.Hash)
+	if !h.Available() {
+		return false
+	}
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher1.Cmp(pub.P) >= 0 || cipher2.Cmp(pub.P) >= 0 { //  (c1, c2) < P
+		return false
+	}
+	q := pub.Order()
+	a, b, z := proof.A, proof.B, proof.Response
+	if z.Sign() < 0 || z.Cmp(q) >= 0 {
+		return false
+	}
+	// the equations only determine the exponents modulo q if all bases lie
+	// in the subgroup of order q; otherwise a factor -1 would go unnoticed
+	// whenever the challenge is even
+	if !pub.InSubgroup(cipher1) || !pub.InSubgroup(a) || !pub.InSubgroup(b) {
+		return false
+	}
+
+	// s = c2 * m^(-1) mod p
+	s := new(big.Int).ModInverse(new(big.Int).SetBytes(plaintext), pub.P)
+	if s == nil {
+		return false
+	}
+	s.Mod(s.Mul(s, cipher2), pub.P)
+	if !pub.InSubgroup(s) {
+		return false
+	}
+
+	// c = H(p, g, y, c1, c2, s, a, b) mod q
+	c := challenge(h, q, "elgamal-decryption", pub.P, pub.G, pub.Y, cipher1, cipher2, s, a, b)
+
+	// g^z = a * y^c mod p
+	left := new(big.Int).Exp(pub.G, z, pub.P)
+	right := new(big.Int).Mod(
+		new(big.Int).Mul(a, new(big.Int).Exp(pub.Y, c, pub.P)),
+		pub.P,
+	)
+	if left.Cmp(right) != 0 {
+		return false
+	}
+
+	// c1^z = b * s^c mod p
+	left = new(big.Int).Exp(cipher1, z, pub.P)
+	right = new(big.Int).Mod(
+		new(big.Int).Mul(b, new(big.Int).Exp(s, c, pub.P)),
+		pub.P,
+	)
+	return left.Cmp(right) == 0
+}
+
+// EqualityProof is a non-interactive Chaum-Pedersen proof that two ciphers
+// under the same key hold the same plain text. The ratio (d1, d2) of the
+// ciphers encrypts m/m', which is 1 exactly if d2 = d1^x mod p, so the
+// proof shows that log_g(y) = log_d1(d2) without revealing x.
+type EqualityProof struct {
+	A        *big.Int    // a = g^v mod p
+	B        *big.Int    // b = d1^v mod p
+	Response *big.Int    // z = v + c*x mod q
+	Hash     crypto.Hash // hash of the challenge, SHA-256 if zero
+}
+
+// ProvePlaintextEquality proves that the ciphers ct1 and ct2 hold the same
+// plain text under priv, without revealing it or x. It returns an error if
+// the plain texts differ. The challenge is
+// c = SHA-256(p, g, y, ct1, ct2, a, b) mod q.
+func ProvePlaintextEquality(priv *PrivateKey, ct1, ct2 *Ciphertext) (*EqualityProof, error) {
+	return ProvePlaintextEqualityHash(priv, ct1, ct2, crypto.SHA256)
+}
+
+// ProvePlaintextEqualityHash is like ProvePlaintextEquality, but it computes
+// the challenge with the hash function h, which is recorded in the proof.
+func ProvePlaintextEqualityHash(priv *PrivateKey, ct1, ct2 *Ciphertext, h crypto.Hash) (*EqualityProof, error) {
+	if !h.Available() {
+		return nil, errors.New("elgamal: hash function is not available")
+	}
+	if ct1 == nil || ct2 == nil {
+		return nil, errors.New("elgamal: missing cipher parts")
+	}
+	if err := priv.checkCiphertexts([]Ciphertext{*ct1, *ct2}); err != nil {
+		return nil, err
+	}
+	q := priv.Order()
+	d := priv.cipherRatio(*ct1, *ct2)
+	// d2 = d1^x mod p
+	if priv.expX(d.C1).Cmp(d.C2) != 0 {
+		return nil, errors.New("elgamal: plaintexts are not equal")
+	}
+
+	// choose random integer v from {1...(q-1)}
+	v, err := randExponent(rand.Reader, q)
+	if err != nil {
+		return nil, err
+	}
+	// a = g^v mod p
+	a := new(big.Int).Exp(priv.G, v, priv.P)
+	// b = d1^v mod p
+	b := new(big.Int).Exp(d.C1, v, priv.P)
+	// c = H(p, g, y, ct1, ct2, a, b) mod q
+	c := challenge(h, q, "elgamal-equality",
+		priv.P, priv.G, priv.Y, ct1.C1, ct1.C2, ct2.C1, ct2.C2, a, b)
+	// z = v + c*x mod q
+	z := new(big.Int).Mod(
+		new(big.Int).Add(v, new(big.Int).Mul(c, priv.X)),
+		q,
+	)
+	return &EqualityProof{A: a, B: b, Response: z, Hash: h}, nil
+}
+
+// VerifyPlaintextEquality verifies a proof produced by ProvePlaintextEquality.
+// It reports whether ct1 and ct2 hold the same plain text under pub, by
+// checking g^z = a * y^c mod p and d1^z = b * d2^c mod p for the ratio
+// (d1, d2) of the ciphers. It also requires d1, d2, a and b to lie in the
+// subgroup of order q.
+func VerifyPlaintextEquality(pub *PublicKey, ct1, ct2 *Ciphertext, proof *EqualityProof) bool {
+	if ct1 == nil || ct2 == nil || proof == nil ||
+		proof.A == nil || proof.B == nil || proof.Response == nil {
+		return false
+	}
+	h := proofHash(proof.Hash)
+	if !h.Available() {
+		return false
+	}
+	if pub.checkCiphertexts([]Ciphertext{*ct1, *ct2}) != nil {
+		return false
+	}
+	q := pub.Order()
+	a, b, z := proof.A, proof.B, proof.Response
+	if z.Sign() < 0 || z.Cmp(q) >= 0 {
+		return false
+	}
+
+	d := pub.cipherRatio(*ct1, *ct2)
+	// as in VerifyDecryption, a ratio d2 = -d1^x would pass for every even
+	// challenge unless all bases lie in the subgroup of order q
+	if !pub.InSubgroup(d.C1) || !pub.InSubgroup(d.C2) || !pub.InSubgroup(a) || !pub.InSubgroup(b) {
+		return false
+	}
+	// c = H(p, g, y, ct1, ct2, a, b) mod q
+	c := challenge(h, q, "elgamal-equality",
+		pub.P, pub.G, pub.Y, ct1.C1, ct1.C2, ct2.C1, ct2.C2, a, b)
+
+	// g^z = a * y^c mod p
+	left := new(big.Int).Exp(pub.G, z, pub.P)
+	right := mulMod(new(big.Int), a, new(big.Int).Exp(pub.Y, c, pub.P), pub.P)
+	if left.Cmp(right) != 0 {
+		return false
+	}
+
+	// d1^z = b * d2^c mod p
+	left = new(big.Int).Exp(d.C1, z, pub.P)
+	right = mulMod(right, b, new(big.Int).Exp(d.C2, c, pub.P), pub.P)
+	return left.Cmp(right) == 0
+}
+
+// cipherRatio returns the cipher ct1 / ct2 = (c1 * c1'^(-1), c2 * c2'^(-1))
+// mod p, which holds the ratio of the plain texts. Both ciphers must have
+// passed checkCiphertexts, so that their parts are invertible.
+func (pub *PublicKey) cipherRatio(ct1, ct2 Ciphertext) Ciphertext {
+	d1 := new(big.Int).ModInverse(ct2.C1, pub.P)
+	d2 := new(big.Int).ModInverse(ct2.C2, pub.P)
+	return Ciphertext{
+		C1: mulMod(d1, d1, ct1.C1, pub.P),
+		C2: mulMod(d2, d2, ct1.C2, pub.P),
+	}
+}
+
+// OneProof is a non-interactive Chaum-Pedersen proof that a cipher
+// (c1, c2) = (g^k, y^k) mod p is an encryption of one, i.e. that
+// log_g(c1) = log_y(c2), made by the party that knows the random k.
+type OneProof struct {
+	A        *big.Int    // a = g^v mod p
+	B        *big.Int    // b = y^v mod p
+	Response *big.Int    // z = v + c*k mod q
+	Hash     crypto.Hash // hash of the challenge, SHA-256 if zero
+}
+
+// IsEncryptionOfOne reports whether ct is an encryption of one under priv,
+// by checking c2 = c1^x mod p.
+func (priv *PrivateKey) IsEncryptionOfOne(ct *Ciphertext) bool {
+	if ct == nil || priv.checkCiphertexts([]Ciphertext{*ct}) != nil {
+		return false
+	}
+	return priv.expX(ct.C1).Cmp(ct.C2) == 0
+}
+
+// ProveEncryptionOfOne proves that ct is an encryption of one under pub,
+// given the random k of the cipher as returned by EncryptWithAudit, without
+// revealing k. Anyone holding only the public key can verify the proof with
+// VerifyEncryptionOfOne. It returns an error unless c1 = g^k and c2 = y^k.
+// The challenge is c = SHA-256(p, g, y, c1, c2, a, b) mod q.
+func ProveEncryptionOfOne(pub *PublicKey, ct *Ciphertext, k *big.Int) (*OneProof, error) {
+	return ProveEncryptionOfOneHash(pub, ct, k, crypto.SHA256)
+}
+
+// ProveEncryptionOfOneHash is like ProveEncryptionOfOne, but it computes the
+// challenge with the hash function h, which is recorded in the proof.
+func ProveEncryptionOfOneHash(pub *PublicKey, ct *Ciphertext, k *big.Int, h crypto.Hash) (*OneProof, error) {
+	if !h.Available() {
+		return nil, errors.New("elgamal: hash function is not available")
+	}
+	if ct == nil {
+		return nil, errors.New("elgamal: missing cipher parts")
+	}
+	if err := pub.checkCiphertexts([]Ciphertext{*ct}); err != nil {
+		return nil, err
+	}
+	q := pub.Order()
+	if k == nil || k.Sign() <= 0 || k.Cmp(q) >= 0 {
+		return nil, errors.New("elgamal: k is out of range")
+	}
+	// c1 = g^k mod p, c2 = y^k mod p
+	if pub.expG(k).Cmp(ct.C1) != 0 || pub.expY(k).Cmp(ct.C2) != 0 {
+		return nil, errors.New("elgamal: cipher is not an encryption of one under k")
+	}
+
+	// choose random integer v from {1...(q-1)}
+	v, err := randExponent(rand.Reader, q)
+	if err != nil {
+		return nil, err
+	}
+	// a = g^v mod p
+	a := pub.expG(v)
+	// b = y^v mod p
+	b := pub.expY(v)
+	// c = H(p, g, y, c1, c2, a, b) mod q
+	c := challenge(h, q, "elgamal-one", pub.P, pub.G, pub.Y, ct.C1, ct.C2, a, b)
+	// z = v + c*k mod q
+	z := new(big.Int).Mod(
+		new(big.Int).Add(v, new(big.Int).Mul(c, k)),
+		q,
+	)
+	return &OneProof{A: a, B: b, Response: z, Hash: h}, nil
+}
+
+// VerifyEncryptionOfOne verifies a proof produced by ProveEncryptionOfOne.
+// It reports whether ct is an encryption of one under pub, by checking
+// g^z = a * c1^c mod p and y^z = b * c2^c mod p, where c1, c2, a and b must
+// lie in the subgroup of order q.
+func VerifyEncryptionOfOne(pub *PublicKey, ct *Ciphertext, proof *OneProof) bool {
+	if ct == nil || proof == nil || proof.A == nil || proof.B == nil || proof.Response == nil {
+		return false
+	}
+	h := proofHash(proof.Hash)
+	if !h.Available() {
+		return false
+	}
+	if pub.checkCiphertexts([]Ciphertext{*ct}) != nil {
+		return false
+	}
+	q := pub.Order()
+	a, b, z := proof.A, proof.B, proof.Response
+	if z.Sign() < 0 || z.Cmp(q) >= 0 {
+		return false
+	}
+	// a cipher (g^k, -y^k) of p - 1 would pass for every even challenge
+	// unless all values lie in the subgroup of order q
+	if !pub.InSubgroup(ct.C1) || !pub.InSubgroup(ct.C2) || !pub.InSubgroup(a) || !pub.InSubgroup(b) {
+		return false
+	}
+
+	// c = H(p, g, y, c1, c2, a, b) mod q
+	c := challenge(h, q, "elgamal-one", pub.P, pub.G, pub.Y, ct.C1, ct.C2, a, b)
+
+	// g^z = a * c1^c mod p
+	left := new(big.Int).Exp(pub.G, z, pub.P)
+	right := mulMod(new(big.Int), a, new(big.Int).Exp(ct.C1, c, pub.P), pub.P)
+	if left.Cmp(right) != 0 {
+		return false
+	}
+
+	// y^z = b * c2^c mod p
+	left = new(big.Int).Exp(pub.Y, z, pub.P)
+	right = mulMod(right, b, new(big.Int).Exp(ct.C2, c, pub.P), pub.P)
+	return left.Cmp(right) == 0
+}
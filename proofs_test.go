@@ -0,0 +1,322 @@
+package elgamal
+
+import (
+	"crypto"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyDecryptionRejectsNegatedSecret(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	q := priv.Order()
+
+	c1, c2, err := priv.Encrypt([]byte("vote"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher1, cipher2 := new(big.Int).SetBytes(c1), new(big.Int).SetBytes(c2)
+	plaintext, proof, err := ProveDecryption(priv, c1, c2)
+	if err != nil || !VerifyDecryption(pub, c1, c2, plaintext, proof) {
+		t.Fatal("honest proof rejected:", err)
+	}
+
+	// claim the plain text p - m with the shared secret -s, and retry until
+	// the challenge is even, so that (-s)^c = s^c
+	m := new(big.Int).SetBytes([]byte("vote"))
+	forged := new(big.Int).Sub(priv.P, m).Bytes()
+	s := new(big.Int).Exp(cipher1, priv.X, priv.P)
+	negS := new(big.Int).Sub(priv.P, s)
+	for i := 0; i < 64; i++ {
+		v, err := randExponent(rand.Reader, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := new(big.Int).Exp(priv.G, v, priv.P)
+		b := new(big.Int).Exp(cipher1, v, priv.P)
+		c := challenge(crypto.SHA256, q, "elgamal-decryption", priv.P, priv.G, priv.Y, cipher1, cipher2, negS, a, b)
+		if c.Bit(0) != 0 {
+			continue
+		}
+		z := new(big.Int).Mod(new(big.Int).Add(v, new(big.Int).Mul(c, priv.X)), q)
+		proof := &DecryptionProof{A: a, B: b, Response: z, Hash: crypto.SHA256}
+		if VerifyDecryption(pub, c1, c2, forged, proof) {
+			t.Fatal("forged plain text p - m accepted")
+		}
+		return
+	}
+	t.Fatal("no even challenge found")
+}
+
+func TestVerifyPlaintextEqualityRejectsNegatedRatio(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	q := priv.Order()
+
+	ct1, err := pub.EncryptBigInt(big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, err := pub.EncryptBigInt(new(big.Int).Sub(priv.P, big.NewInt(5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProvePlaintextEquality(priv, ct1, ct2); err == nil {
+		t.Fatal("proved equality of 5 and p - 5")
+	}
+
+	// the ratio satisfies d2 = -d1^x, which passes for every even challenge
+	d := pub.cipherRatio(*ct1, *ct2)
+	for i := 0; i < 64; i++ {
+		v, err := randExponent(rand.Reader, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := new(big.Int).Exp(priv.G, v, priv.P)
+		b := new(big.Int).Exp(d.C1, v, priv.P)
+		c := challenge(crypto.SHA256, q, "elgamal-equality",
+			priv.P, priv.G, priv.Y, ct1.C1, ct1.C2, ct2.C1, ct2.C2, a, b)
+		if c.Bit(0) != 0 {
+			continue
+		}
+		z := new(big.Int).Mod(new(big.Int).Add(v, new(big.Int).Mul(c, priv.X)), q)
+		proof := &EqualityProof{A: a, B: b, Response: z, Hash: crypto.SHA256}
+		if VerifyPlaintextEquality(pub, ct1, ct2, proof) {
+			t.Fatal("forged equality of 5 and p - 5 accepted")
+		}
+		return
+	}
+	t.Fatal("no even challenge found")
+}
+
+func TestProveNilCiphertexts(t *testing.T) {
+	priv := newTestKey(t)
+	ct, err := priv.EncryptCT([]byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProvePlaintextEquality(priv, nil, ct); err == nil {
+		t.Error("ProvePlaintextEquality accepted a nil cipher")
+	}
+	if _, err := ProvePlaintextEquality(priv, ct, nil); err == nil {
+		t.Error("ProvePlaintextEquality accepted a nil cipher")
+	}
+	if _, err := ProveEncryptionOfOne(&priv.PublicKey, nil, one); err == nil {
+		t.Error("ProveEncryptionOfOne accepted a nil cipher")
+	}
+}
+
+func TestPlaintextEqualityProof(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	ct1, _ := pub.EncryptBigInt(big.NewInt(42))
+	ct2, _ := pub.EncryptBigInt(big.NewInt(42))
+	proof, err := ProvePlaintextEquality(priv, ct1, ct2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyPlaintextEquality(pub, ct1, ct2, proof) {
+		t.Error("honest equality proof rejected")
+	}
+	ct3, _ := pub.EncryptBigInt(big.NewInt(43))
+	if VerifyPlaintextEquality(pub, ct1, ct3, proof) {
+		t.Error("equality proof accepted for another cipher")
+	}
+}
+
+func TestEncryptionOfOneProof(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	k, err := randExponent(rand.Reader, priv.Order())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := &Ciphertext{C1: pub.expG(k), C2: pub.expY(k)}
+	if !priv.IsEncryptionOfOne(ct) {
+		t.Fatal("IsEncryptionOfOne rejected an encryption of one")
+	}
+	proof, err := ProveEncryptionOfOne(pub, ct, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyEncryptionOfOne(pub, ct, proof) {
+		t.Error("honest proof of one rejected")
+	}
+}
+
+func TestVerifyEncryptionOfOneRejectsMinusOne(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	q := priv.Order()
+
+	// (g^k, -y^k) encrypts p - 1, yet satisfies both equations whenever the
+	// challenge is even
+	k, err := randExponent(rand.Reader, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := &Ciphertext{C1: pub.expG(k), C2: new(big.Int).Sub(priv.P, pub.expY(k))}
+	if priv.IsEncryptionOfOne(ct) {
+		t.Fatal("IsEncryptionOfOne accepted an encryption of p - 1")
+	}
+	for i := 0; i < 64; i++ {
+		v, err := randExponent(rand.Reader, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, b := pub.expG(v), pub.expY(v)
+		c := challenge(crypto.SHA256, q, "elgamal-one", priv.P, priv.G, priv.Y, ct.C1, ct.C2, a, b)
+		if c.Bit(0) != 0 {
+			continue
+		}
+		z := new(big.Int).Mod(new(big.Int).Add(v, new(big.Int).Mul(c, k)), q)
+		proof := &OneProof{A: a, B: b, Response: z, Hash: crypto.SHA256}
+		if VerifyEncryptionOfOne(pub, ct, proof) {
+			t.Fatal("forged proof of one accepted for p - 1")
+		}
+		return
+	}
+	t.Fatal("no even challenge found")
+}
+
+func TestKnowledgeProof(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	proof, err := ProveKnowledge(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyKnowledge(pub, proof) {
+		t.Fatal("honest proof of knowledge rejected")
+	}
+
+	tampered := *proof
+	tampered.Response = new(big.Int).Add(proof.Response, one)
+	if VerifyKnowledge(pub, &tampered) {
+		t.Error("accepted a tampered response")
+	}
+	tampered = *proof
+	tampered.Commitment = new(big.Int).Mul(proof.Commitment, priv.G)
+	if VerifyKnowledge(pub, &tampered) {
+		t.Error("accepted a tampered commitment")
+	}
+	other := newTestKey(t)
+	if VerifyKnowledge(&other.PublicKey, proof) {
+		t.Error("accepted the proof for another key")
+	}
+	if VerifyKnowledge(pub, nil) {
+		t.Error("accepted a nil proof")
+	}
+}
+
+func TestVerifyDecryptionRejectsOtherPlaintext(t *testing.T) {
+	priv := newTestKey(t)
+	c1, c2, err := priv.Encrypt([]byte("yes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, proof, err := ProveDecryption(priv, c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyDecryption(&priv.PublicKey, c1, c2, []byte("no"), proof) {
+		t.Error("proof accepted for another plain text")
+	}
+	if VerifyDecryption(&priv.PublicKey, c1, c2, []byte("yes"), nil) {
+		t.Error("nil proof accepted")
+	}
+}
+
+func TestProofHash(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+
+	proof, err := ProveKnowledgeHash(priv, crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyKnowledge(pub, proof) {
+		t.Fatal("valid SHA-512 proof rejected")
+	}
+	proof.Hash = crypto.SHA256
+	if VerifyKnowledge(pub, proof) {
+		t.Error("SHA-512 proof verified as SHA-256")
+	}
+
+	proof, err = ProveKnowledge(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.Hash = 0
+	if !VerifyKnowledge(pub, proof) {
+		t.Error("zero hash does not default to SHA-256")
+	}
+	proof.Hash = crypto.SHA512
+	if VerifyKnowledge(pub, proof) {
+		t.Error("SHA-256 proof verified as SHA-512")
+	}
+
+	c1, c2, err := pub.Encrypt([]byte("hash"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, dproof, err := ProveDecryptionHash(priv, c1, c2, crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyDecryption(pub, c1, c2, m, dproof) {
+		t.Fatal("valid SHA-512 decryption proof rejected")
+	}
+	dproof.Hash = crypto.SHA256
+	if VerifyDecryption(pub, c1, c2, m, dproof) {
+		t.Error("SHA-512 decryption proof verified as SHA-256")
+	}
+
+	ct1, _ := pub.EncryptBigInt(big.NewInt(42))
+	ct2, _ := pub.EncryptBigInt(big.NewInt(42))
+	eproof, err := ProvePlaintextEqualityHash(priv, ct1, ct2, crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyPlaintextEquality(pub, ct1, ct2, eproof) {
+		t.Fatal("valid SHA-512 equality proof rejected")
+	}
+	eproof.Hash = crypto.SHA256
+	if VerifyPlaintextEquality(pub, ct1, ct2, eproof) {
+		t.Error("SHA-512 equality proof verified as SHA-256")
+	}
+
+	k, err := randExponent(rand.Reader, priv.Order())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := &Ciphertext{C1: pub.expG(k), C2: pub.expY(k)}
+	oproof, err := ProveEncryptionOfOneHash(pub, ct, k, crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyEncryptionOfOne(pub, ct, oproof) {
+		t.Fatal("valid SHA-512 proof of one rejected")
+	}
+	oproof.Hash = crypto.SHA256
+	if VerifyEncryptionOfOne(pub, ct, oproof) {
+		t.Error("SHA-512 proof of one verified as SHA-256")
+	}
+}
+
+func TestProveDecryptionSecureMode(t *testing.T) {
+	priv := newTestKey(t)
+	priv.SecureMode = true
+	c1, c2, err := priv.Encrypt([]byte("ladder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, proof, err := ProveDecryption(priv, c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyDecryption(&priv.PublicKey, c1, c2, m, proof) {
+		t.Error("proof of a key in SecureMode rejected")
+	}
+}
@@ -0,0 +1,36 @@
+package elgamal
+
+import "errors"
+
+var ErrTampered = errors.New("elgamal: decrypted value is not a subgroup element")
+
+// EncryptQR encrypts a plain text like Encrypt, but it first encodes the
+// message into the subgroup of prime order q, so that the cipher does not
+// leak whether the message is a quadratic residue modulo p. The message m
+// is shifted to m + 1, which is used as is if it lies in the subgroup and
+// replaced by p - (m + 1) otherwise. Since p is a safe prime, exactly one
+// of the two lies in the subgroup. It returns ErrMessageLarge if m >= q.
+func (pub *PublicKey) EncryptQR(message []byte) ([]byte, []byte, error) {
+	return pub.EncryptWithEncoder(QREncoder{Key: pub}, message)
+}
+
+// DecryptQR decrypts a cipher produced by EncryptQR and reverses the
+// encoding of the message into the subgroup.
+func (priv *PrivateKey) DecryptQR(cipher1, cipher2 []byte) ([]byte, error) {
+	return priv.DecryptWithEncoder(QREncoder{Key: &priv.PublicKey}, cipher1, cipher2)
+}
+
+// DecryptVerify decrypts a cipher produced by EncryptQR like DecryptQR, but
+// it first checks that the recovered value lies in the subgroup of prime
+// order q, as every honestly encoded message does. It returns ErrTampered
+// otherwise, e.g. if c2 was multiplied by a quadratic non-residue.
+func (priv *PrivateKey) DecryptVerify(cipher1, cipher2 []byte) ([]byte, error) {
+	m, err := priv.DecryptBig(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+	if !priv.InSubgroup(m) {
+		return nil, ErrTampered
+	}
+	return QREncoder{Key: &priv.PublicKey}.Decode(m)
+}
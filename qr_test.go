@@ -0,0 +1,55 @@
+package elgamal
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestEncryptQR(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	qm1 := new(big.Int).Sub(priv.Order(), one)
+	for _, msg := range [][]byte{{}, {0}, {1}, {2}, []byte("quadratic residue"), qm1.Bytes()} {
+		c1, c2, err := priv.EncryptQR(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pub.InSubgroup(new(big.Int).SetBytes(c1)) || !pub.InSubgroup(new(big.Int).SetBytes(c2)) {
+			t.Errorf("%x: cipher part outside of the subgroup", msg)
+		}
+		m, err := priv.DecryptQR(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, new(big.Int).SetBytes(msg).Bytes()) {
+			t.Errorf("got %x, want %x", m, msg)
+		}
+	}
+	if _, _, err := priv.EncryptQR(priv.Order().Bytes()); err != ErrMessageLarge {
+		t.Errorf("m = q: got %v, want ErrMessageLarge", err)
+	}
+}
+
+func TestDecryptVerify(t *testing.T) {
+	priv := newTestKey(t)
+	for _, msg := range [][]byte{[]byte("verify"), {0x01}, {0x00}} {
+		c1, c2, err := priv.EncryptQR(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := priv.DecryptVerify(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := new(big.Int).SetBytes(msg).Bytes(); !bytes.Equal(m, want) {
+			t.Errorf("got %x, want %x", m, want)
+		}
+
+		// c2 * (p - 1) encrypts -m, which lies outside the subgroup
+		mauled := new(big.Int).Sub(priv.P, new(big.Int).SetBytes(c2))
+		if _, err := priv.DecryptVerify(c1, mauled.Bytes()); err != ErrTampered {
+			t.Errorf("mauled cipher of %x: got %v, want ErrTampered", msg, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package elgamal
+
+import (
+	"crypto"
+	"errors"
+	"math/big"
+)
+
+// ReEncKey is a one-way re-encryption key from one Elgamal key to another
+// key in the same group, as produced by GenerateReEncryptionKey.
+//
+// The key is K = x_from + z mod q, where z is derived from the
+// Diffie-Hellman secret y_to^(x_from) = y_from^(x_to) of both keys. The
+// proxy holding K learns neither x_from nor the plain texts it transforms,
+// and it cannot transform ciphers from the target back to the source.
+// Only the public key of the target is needed, so the secret exponent of
+// the target is never at risk, not even if the proxy colludes with the
+// source. A proxy colluding with the target, however, can recover x_from
+// as K - z; without pairings, this is inherent to schemes that need no
+// cooperation of the target.
+type ReEncKey struct {
+	From *PublicKey
+	To   *PublicKey
+	K    *big.Int // x_from + z mod q
+}
+
+// GenerateReEncryptionKey returns a key that lets a proxy transform ciphers
+// under from into ciphers under to, see ReEncKey for the trust assumptions.
+// Both keys must use the same group <p,g>, and the public value of to must
+// lie in the subgroup of prime order q.
+func GenerateReEncryptionKey(from *PrivateKey, to *PublicKey) (*ReEncKey, error) {
+	if from.P.Cmp(to.P) != 0 || from.G.Cmp(to.G) != 0 {
+		return nil, errors.New("elgamal: re-encryption keys must share the group")
+	}
+	q := from.Order()
+	if from.X == nil || from.X.Sign() <= 0 || from.X.Cmp(q) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	// a public value outside of the subgroup would leave z guessable
+	if to.Y.Cmp(one) == 0 || !to.InSubgroup(to.Y) {
+		return nil, ErrInvalidPublicValue
+	}
+
+	// k = x_from + z mod q
+	z := reEncryptionSecret(&from.PublicKey, to, from.expX(to.Y))
+	k := new(big.Int).Add(from.X, z)
+	k.Mod(k, q)
+	return &ReEncKey{
+		From: &from.PublicKey,
+		To:   to,
+		K:    k,
+	}, nil
+}
+
+// reEncryptionSecret derives z = H(y_from, y_to, s) mod q from the
+// Diffie-Hellman secret s = y_to^(x_from) = y_from^(x_to) mod p.
+func reEncryptionSecret(from, to *PublicKey, s *big.Int) *big.Int {
+	return challenge(crypto.SHA256, from.Order(), "elgamal-reencryption", from.Y, to.Y, s)
+}
+
+// ReEncrypt transforms the cipher (c1, c2) under rk.From into a cipher
+// under rk.To of the same plain text, which the target decrypts with
+// DecryptReEncrypted. c1 is kept, and c2' = c2 * c1^(-k) mod p, since
+// c2 = m * c1^(x_from) turns into m * c1^(-z).
+func ReEncrypt(rk *ReEncKey, c1, c2 []byte) ([]byte, []byte, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	cipher2 := new(big.Int).SetBytes(c2)
+	p := rk.To.P
+	if cipher1.Cmp(p) >= 0 || cipher2.Cmp(p) >= 0 { //  (c1, c2) < P
+		return nil, nil, ErrCipherLarge
+	}
+	if !coprime(cipher1, p) {
+		return nil, nil, ErrInvalidCiphertext
+	}
+
+	// mask = c1^(-k) mod p
+	mask := new(big.Int).Exp(cipher1, rk.K, p)
+	if mask.ModInverse(mask, p) == nil {
+		return nil, nil, ErrInvalidCiphertext
+	}
+
+	// c2' = c2 * mask mod p
+	C2 := mulMod(cipher2, cipher2, mask, p)
+	return cipher1.Bytes(), C2.Bytes(), nil
+}
+
+// DecryptReEncrypted decrypts a cipher that ReEncrypt transformed from the
+// public key from into a cipher under priv. The secret z of the
+// re-encryption key is recomputed from y_from^x, and m = c2' * c1^z mod p.
+func (priv *PrivateKey) DecryptReEncrypted(from *PublicKey, cipher1, cipher2 []byte) ([]byte, error) {
+	if from.P.Cmp(priv.P) != 0 || from.G.Cmp(priv.G) != 0 {
+		return nil, errors.New("elgamal: re-encryption keys must share the group")
+	}
+	if from.Y.Cmp(one) == 0 || !priv.InSubgroup(from.Y) {
+		return nil, ErrInvalidPublicValue
+	}
+	c1, c2, err := priv.parseCipher(cipher1, cipher2)
+	if err != nil {
+		return nil, err
+	}
+
+	// m = c2' * c1^z mod p
+	z := reEncryptionSecret(from, &priv.PublicKey, priv.expX(from.Y))
+	var m *big.Int
+	if priv.SecureMode {
+		m = ladderExp(c1, z, priv.P, priv.P.BitLen())
+	} else {
+		m = new(big.Int).Exp(c1, z, priv.P)
+	}
+	return mulMod(m, m, c2, priv.P).Bytes(), nil
+}
@@ -0,0 +1,71 @@
+package elgamal
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestReEncrypt(t *testing.T) {
+	from, to := newTestKey(t), newTestKey(t)
+	rk, err := GenerateReEncryptionKey(from, &to.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rk.K.Cmp(from.X) == 0 || rk.K.Cmp(to.X) == 0 {
+		t.Fatal("re-encryption key holds a secret exponent")
+	}
+
+	msg := []byte("proxy")
+	c1, c2, err := from.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1, r2, err := ReEncrypt(rk, c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secure := range []bool{false, true} {
+		to.SecureMode = secure
+		got, err := to.DecryptReEncrypted(&from.PublicKey, r1, r2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("SecureMode %v: got %q, want %q", secure, got, msg)
+		}
+	}
+	if got, err := from.Decrypt(r1, r2); err == nil && bytes.Equal(got, msg) {
+		t.Error("re-encrypted cipher still decrypts under the source")
+	}
+	if got, err := to.DecryptReEncrypted(&from.PublicKey, c1, c2); err == nil && bytes.Equal(got, msg) {
+		t.Error("target decrypted a cipher that was not re-encrypted")
+	}
+
+	third := newTestKey(t)
+	if got, err := third.DecryptReEncrypted(&from.PublicKey, r1, r2); err == nil && bytes.Equal(got, msg) {
+		t.Error("another key decrypted the re-encrypted cipher")
+	}
+}
+
+func TestGenerateReEncryptionKeyRejectsInvalidTarget(t *testing.T) {
+	from := newTestKey(t)
+	other, err := GenerateKeyInGroup(MODP2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateReEncryptionKey(from, &other.PublicKey); err == nil {
+		t.Error("accepted keys of different groups")
+	}
+
+	// y = p - 1 has order 2, so y^(x_from) would reveal z to the proxy
+	bad := from.PublicKey.Clone()
+	bad.Y = new(big.Int).Sub(from.P, one)
+	if _, err := GenerateReEncryptionKey(from, bad); err != ErrInvalidPublicValue {
+		t.Errorf("target with y of order 2: got %v, want ErrInvalidPublicValue", err)
+	}
+	bad.Y = big.NewInt(1)
+	if _, err := GenerateReEncryptionKey(from, bad); err != ErrInvalidPublicValue {
+		t.Errorf("target with y = 1: got %v, want ErrInvalidPublicValue", err)
+	}
+}
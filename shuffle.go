@@ -0,0 +1,214 @@
+package elgamal
+
+import (
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// shuffleRounds is the number of cut-and-choose rounds of a ShuffleProof.
+// A cheating prover passes verification with probability 2^(-shuffleRounds).
+const shuffleRounds = 80
+
+// ShuffleProof is a non-interactive cut-and-choose proof that a shuffle
+// preserves the multiset of plain texts. In every round the prover commits
+// to a shadow shuffle of the inputs and, depending on a challenge bit,
+// reveals how the shadow is obtained either from the inputs or from the
+// outputs, without revealing the permutation between inputs and outputs.
+type ShuffleProof struct {
+	Shadows      [][]Ciphertext // shadow shuffle of every round
+	Permutations [][]int        // revealed permutation of every round
+	Exponents    [][]*big.Int   // revealed re-randomization exponents of every round
+}
+
+// Shuffle permutes and re-randomizes the passed ciphers, as done by a single
+// server of a mix-net, and proves that the shuffled ciphers hold the same
+// multiset of plain texts as the original ones. The proof is verified by
+// VerifyShuffle; it grows linearly with both the number of ciphers and the
+// number of rounds, so it is simple rather than compact.
+func Shuffle(pub *PublicKey, cts []Ciphertext) (shuffled []Ciphertext, proof *ShuffleProof, err error) {
+	if err := pub.checkCiphertexts(cts); err != nil {
+		return nil, nil, err
+	}
+	n := len(cts)
+
+	// output[pi(i)] = cts[i] * E(1; r_i)
+	pi, r, shuffled, err := pub.shuffle(cts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// shadow[pi_j(i)] = cts[i] * E(1; s_j,i)
+	proof = &ShuffleProof{
+		Shadows:      make([][]Ciphertext, shuffleRounds),
+		Permutations: make([][]int, shuffleRounds),
+		Exponents:    make([][]*big.Int, shuffleRounds),
+	}
+	shadowPerms := make([][]int, shuffleRounds)
+	shadowExps := make([][]*big.Int, shuffleRounds)
+	for j := 0; j < shuffleRounds; j++ {
+		shadowPerms[j], shadowExps[j], proof.Shadows[j], err = pub.shuffle(cts)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c := shuffleChallenge(pub, cts, shuffled, proof.Shadows)
+	q := pub.Order()
+	for j := 0; j < shuffleRounds; j++ {
+		if c.Bit(j) == 0 {
+			// reveal the shadow as a shuffle of the inputs
+			proof.Permutations[j] = shadowPerms[j]
+			proof.Exponents[j] = shadowExps[j]
+			continue
+		}
+
+		// reveal the shadow as a shuffle of the outputs:
+		// shadow[pi_j(i)] = output[pi(i)] * E(1; s_j,i - r_i)
+		perm := make([]int, n)
+		exps := make([]*big.Int, n)
+		for i := 0; i < n; i++ {
+			perm[pi[i]] = shadowPerms[j][i]
+			exps[pi[i]] = new(big.Int).Mod(new(big.Int).Sub(shadowExps[j][i], r[i]), q)
+		}
+		proof.Permutations[j] = perm
+		proof.Exponents[j] = exps
+	}
+	return shuffled, proof, nil
+}
+
+// VerifyShuffle verifies a proof produced by Shuffle. It reports whether
+// shuffled holds the same multiset of plain texts as cts.
+func VerifyShuffle(pub *PublicKey, cts, shuffled []Ciphertext, proof *ShuffleProof) bool {
+	if proof == nil || len(cts) != len(shuffled) ||
+		len(proof.Shadows) != shuffleRounds ||
+		len(proof.Permutations) != shuffleRounds ||
+		len(proof.Exponents) != shuffleRounds {
+		return false
+	}
+	if pub.checkCiphertexts(cts) != nil || pub.checkCiphertexts(shuffled) != nil {
+		return false
+	}
+	for j := 0; j < shuffleRounds; j++ {
+		if pub.checkCiphertexts(proof.Shadows[j]) != nil {
+			return false
+		}
+	}
+
+	c := shuffleChallenge(pub, cts, shuffled, proof.Shadows)
+	for j := 0; j < shuffleRounds; j++ {
+		source := cts
+		if c.Bit(j) == 1 {
+			source = shuffled
+		}
+		if !pub.isShuffleOf(source, proof.Shadows[j], proof.Permutations[j], proof.Exponents[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCiphertexts checks that both parts of every cipher lie in {1...(p-1)}.
+func (pub *PublicKey) checkCiphertexts(cts []Ciphertext) error {
+	for _, ct := range cts {
+		if ct.C1 == nil || ct.C2 == nil {
+			return errors.New("elgamal: missing cipher parts")
+		}
+		if ct.C1.Sign() <= 0 || ct.C2.Sign() <= 0 ||
+			ct.C1.Cmp(pub.P) >= 0 || ct.C2.Cmp(pub.P) >= 0 { //  0 < (c1, c2) < P
+			return ErrCipherLarge
+		}
+	}
+	return nil
+}
+
+// shuffle permutes and re-randomizes the passed ciphers with a random
+// permutation perm and random exponents exps, such that
+// shuffled[perm[i]] = cts[i] * E(1; exps[i]).
+func (pub *PublicKey) shuffle(cts []Ciphertext) (perm []int, exps []*big.Int, shuffled []Ciphertext, err error) {
+	n := len(cts)
+	perm, err = randPermutation(n)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	q := pub.Order()
+	exps = make([]*big.Int, n)
+	shuffled = make([]Ciphertext, n)
+	for i := 0; i < n; i++ {
+		exps[i], err = rand.Int(rand.Reader, q)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		shuffled[perm[i]] = pub.reRandomize(cts[i], exps[i])
+	}
+	return perm, exps, shuffled, nil
+}
+
+// reRandomize returns the cipher ct * E(1; r) = (c1 * g^r mod p, c2 * y^r mod p).
+func (pub *PublicKey) reRandomize(ct Ciphertext, r *big.Int) Ciphertext {
+	c1 := pub.expG(r)
+	c2 := pub.expY(r)
+	return Ciphertext{
+		C1: mulMod(c1, c1, ct.C1, pub.P),
+		C2: mulMod(c2, c2, ct.C2, pub.P),
+	}
+}
+
+// isShuffleOf reports whether target[perm[i]] = source[i] * E(1; exps[i])
+// holds for every i, and perm is a permutation.
+func (pub *PublicKey) isShuffleOf(source, target []Ciphertext, perm []int, exps []*big.Int) bool {
+	n := len(source)
+	if len(target) != n || len(perm) != n || len(exps) != n {
+		return false
+	}
+
+	q := pub.Order()
+	seen := make([]bool, n)
+	for i := 0; i < n; i++ {
+		if perm[i] < 0 || perm[i] >= n || seen[perm[i]] {
+			return false
+		}
+		seen[perm[i]] = true
+		if exps[i] == nil || exps[i].Sign() < 0 || exps[i].Cmp(q) >= 0 {
+			return false
+		}
+
+		ct := pub.reRandomize(source[i], exps[i])
+		if ct.C1.Cmp(target[perm[i]].C1) != 0 || ct.C2.Cmp(target[perm[i]].C2) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// shuffleChallenge computes the challenge bits of a ShuffleProof as
+// c = H(p, g, y, inputs, outputs, shadows) mod q.
+func shuffleChallenge(pub *PublicKey, cts, shuffled []Ciphertext, shadows [][]Ciphertext) *big.Int {
+	values := []*big.Int{pub.P, pub.G, pub.Y}
+	lists := append([][]Ciphertext{cts, shuffled}, shadows...)
+	for _, list := range lists {
+		for _, ct := range list {
+			values = append(values, ct.C1, ct.C2)
+		}
+	}
+	return challenge(crypto.SHA256, pub.Order(), "elgamal-shuffle", values...)
+}
+
+// randPermutation returns a uniformly random permutation of {0...(n-1)}
+// using the Fisher-Yates shuffle.
+func randPermutation(n int) ([]int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		perm[i], perm[j.Int64()] = perm[j.Int64()], perm[i]
+	}
+	return perm, nil
+}
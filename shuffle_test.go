@@ -0,0 +1,53 @@
+package elgamal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShuffle(t *testing.T) {
+	priv := newTestKey(t)
+	pub := &priv.PublicKey
+	var cts []Ciphertext
+	for i := 1; i <= 5; i++ {
+		ct, err := pub.EncryptCT([]byte{byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts = append(cts, *ct)
+	}
+
+	shuffled, proof, err := Shuffle(pub, cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyShuffle(pub, cts, shuffled, proof) {
+		t.Fatal("valid shuffle rejected")
+	}
+
+	var got []int
+	for i := range shuffled {
+		m, err := priv.DecryptCT(&shuffled[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, int(m[0]))
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("shuffle is not a permutation of the inputs: %v", got)
+	}
+
+	bad := slices.Clone(shuffled)
+	ct, err := pub.EncryptCT([]byte{9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad[0] = *ct
+	if VerifyShuffle(pub, cts, bad, proof) {
+		t.Error("accepted a shuffle with a replaced cipher")
+	}
+	if VerifyShuffle(pub, cts, shuffled[1:], proof) {
+		t.Error("accepted a shuffle that drops a cipher")
+	}
+}
@@ -0,0 +1,164 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"math/big"
+)
+
+// Sign signs a hash (which should be the result of hashing a larger message)
+// using the private key priv, according to the classic ElGamal signature
+// scheme. The per-signature k is drawn from rand and is coprime to (p-1).
+// It returns the signature as a pair of integers r and s.
+func Sign(rand io.Reader, priv *PrivateKey, hashed []byte) (r, s *big.Int, err error) {
+	// pm1 = p - 1
+	pm1 := new(big.Int).Sub(priv.P, one)
+	// m as H(m)
+	m := new(big.Int).SetBytes(hashed)
+
+	for {
+		k, err := randomCoprime(rand, pm1)
+		if err != nil {
+			return nil, nil, err
+		}
+		// kinv = k^(-1) mod (p-1)
+		kinv := new(big.Int).ModInverse(k, pm1)
+		if kinv == nil {
+			continue
+		}
+
+		// r = g^k mod p
+		r = new(big.Int).Exp(priv.G, k, priv.P)
+		// xr = x * r
+		xr := new(big.Int).Mul(priv.X, r)
+		// s = [H(m) - xr]k^(-1) mod (p-1)
+		s = new(big.Int).Mod(
+			new(big.Int).Mul(new(big.Int).Sub(m, xr), kinv),
+			pm1,
+		)
+		if s.Sign() == 0 {
+			continue
+		}
+		return r, s, nil
+	}
+}
+
+// Verify verifies the signature (r, s) of hash using the public key pub.
+// It reports whether g^H(m) = y^r * r^s mod p holds with 0 < r < p and
+// 0 < s < p-1.
+func Verify(pub *PublicKey, hashed []byte, r, s *big.Int) bool {
+	// pm1 = p - 1
+	pm1 := new(big.Int).Sub(pub.P, one)
+	if r.Sign() <= 0 || r.Cmp(pub.P) >= 0 {
+		return false
+	}
+	if s.Sign() <= 0 || s.Cmp(pm1) >= 0 {
+		return false
+	}
+
+	// m as H(m)
+	m := new(big.Int).SetBytes(hashed)
+	// ghashm = g^[H(m)] mod p
+	ghashm := new(big.Int).Exp(pub.G, m, pub.P)
+
+	// y^r * r^s mod p
+	YrRs := new(big.Int).Mod(
+		new(big.Int).Mul(
+			new(big.Int).Exp(pub.Y, r, pub.P),
+			new(big.Int).Exp(r, s, pub.P),
+		),
+		pub.P,
+	)
+	return ghashm.Cmp(YrRs) == 0
+}
+
+// randomCoprime chooses random integer k from {2...(n-1)}
+// such that gcd(k, n) is equal to 1.
+func randomCoprime(random io.Reader, n *big.Int) (*big.Int, error) {
+	gcd := new(big.Int)
+	for {
+		k, err := rand.Int(random, new(big.Int).Sub(n, two))
+		if err != nil {
+			return nil, err
+		}
+		k.Add(k, two)
+		if gcd.GCD(nil, nil, k, n).Cmp(one) == 0 {
+			return k, nil
+		}
+	}
+}
+
+// SignDeterministic signs hash like Sign, but it derives the per-signature k
+// deterministically from the secret exponent x and hash instead of drawing
+// it from a random source, in the manner of RFC 6979. Candidates for k are
+// read from an HMAC-DRBG with SHA-256 seeded with x and hash reduced modulo
+// p - 1, so signing the same hash twice yields the same signature, and the
+// signature does not depend on the quality of an entropy source.
+func SignDeterministic(priv *PrivateKey, hashed []byte) (r, s *big.Int, err error) {
+	return Sign(newNonceReader(priv, hashed), priv, hashed)
+}
+
+// nonceReader is the HMAC-DRBG of RFC 6979, section 3.2. Each call to Read
+// after the first one updates the state as for a rejected candidate before
+// generating further output.
+type nonceReader struct {
+	k, v    []byte
+	started bool
+}
+
+// newNonceReader seeds a nonceReader with int2octets(x) and
+// bits2octets(hash) for the modulus p - 1 of the signature scheme.
+func newNonceReader(priv *PrivateKey, hashed []byte) *nonceReader {
+	// pm1 = p - 1
+	pm1 := new(big.Int).Sub(priv.P, one)
+	rlen := (pm1.BitLen() + 7) / 8
+
+	// bits2int(hash) keeps the leftmost bits of the hash
+	h := new(big.Int).SetBytes(hashed)
+	if excess := len(hashed)*8 - pm1.BitLen(); excess > 0 {
+		h.Rsh(h, uint(excess))
+	}
+	h.Mod(h, pm1)
+	seed := append(priv.X.FillBytes(make([]byte, rlen)), h.FillBytes(make([]byte, rlen))...)
+
+	d := &nonceReader{
+		k: make([]byte, sha256.Size),
+		v: bytes.Repeat([]byte{0x01}, sha256.Size),
+	}
+	// K = HMAC_K(V || 0x00 || seed), V = HMAC_K(V)
+	// K = HMAC_K(V || 0x01 || seed), V = HMAC_K(V)
+	for _, sep := range []byte{0x00, 0x01} {
+		d.k = d.mac(d.v, []byte{sep}, seed)
+		d.v = d.mac(d.v)
+	}
+	return d
+}
+
+// mac returns HMAC_K(data...) under the current key K.
+func (d *nonceReader) mac(data ...[]byte) []byte {
+	m := hmac.New(sha256.New, d.k)
+	for _, b := range data {
+		m.Write(b)
+	}
+	return m.Sum(nil)
+}
+
+// Read fills p with the output T = V || V || ... of the DRBG, where
+// V = HMAC_K(V) is recomputed before every block.
+func (d *nonceReader) Read(p []byte) (int, error) {
+	if d.started {
+		// K = HMAC_K(V || 0x00), V = HMAC_K(V)
+		d.k = d.mac(d.v, []byte{0x00})
+		d.v = d.mac(d.v)
+	}
+	d.started = true
+
+	for n := 0; n < len(p); {
+		d.v = d.mac(d.v)
+		n += copy(p[n:], d.v)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,66 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyKnownAnswer(t *testing.T) {
+	// Stinson, Cryptography: Theory and Practice, example 7.1:
+	// p = 467, g = 2, x = 127, k = 213 and H(m) = 100
+	pub := &PublicKey{P: big.NewInt(467), G: big.NewInt(2), Y: big.NewInt(132)}
+	hashed := []byte{100}
+	if !Verify(pub, hashed, big.NewInt(29), big.NewInt(51)) {
+		t.Error("known-answer signature rejected")
+	}
+	if Verify(pub, []byte{101}, big.NewInt(29), big.NewInt(51)) {
+		t.Error("known-answer signature accepted for another hash")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv := newTestKey(t)
+	hashed := sha256.Sum256([]byte("signed"))
+	r, s, err := Sign(rand.Reader, priv, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(&priv.PublicKey, hashed[:], r, s) {
+		t.Fatal("signature rejected")
+	}
+	hashed[0] ^= 1
+	if Verify(&priv.PublicKey, hashed[:], r, s) {
+		t.Error("signature accepted for a modified hash")
+	}
+}
+
+func TestSignDeterministic(t *testing.T) {
+	priv := newTestKey(t)
+	one := sha256.Sum256([]byte("one"))
+	other := sha256.Sum256([]byte("two"))
+
+	r1, s1, err := SignDeterministic(priv, one[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, s2, err := SignDeterministic(priv, one[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.Cmp(r2) != 0 || s1.Cmp(s2) != 0 {
+		t.Error("signatures of the same message differ")
+	}
+	if !Verify(&priv.PublicKey, one[:], r1, s1) {
+		t.Error("valid signature rejected")
+	}
+
+	r3, _, err := SignDeterministic(priv, other[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.Cmp(r3) == 0 {
+		t.Error("different messages are signed with the same k")
+	}
+}
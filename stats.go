@@ -0,0 +1,40 @@
+package elgamal
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+)
+
+// GenStats records the work done by GenerateKeyStats, which helps callers
+// to choose a tradeoff between bit size, probability and generation time.
+type GenStats struct {
+	// Candidates is the number of primes q for which p = 2q + 1 was tested.
+	Candidates int
+	// MillerRabinRounds is the number of Miller-Rabin rounds requested for
+	// testing the candidates p, i.e. Candidates * probability.
+	MillerRabinRounds int
+	// Elapsed is the time spent generating the key.
+	Elapsed time.Duration
+}
+
+// GenerateKeyStats is like GenerateKey, but it also returns statistics
+// about the search for the safe prime P.
+func GenerateKeyStats(bitsize, probability int) (*PrivateKey, *GenStats, error) {
+	stats := new(GenStats)
+	start := time.Now()
+
+	// p is prime number
+	// q is prime group order
+	// g is cyclic group generator Zp
+	p, q, g, err := genStats(context.Background(), rand.Reader, bitsize, probability, stats)
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, err := newPrivateKey(rand.Reader, p, q, g)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats.Elapsed = time.Since(start)
+	return priv, stats, nil
+}
@@ -0,0 +1,25 @@
+package elgamal
+
+import "testing"
+
+func TestGenerateKeyStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches for a safe prime")
+	}
+	priv, stats, err := GenerateKeyStats(512, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := priv.Validate(); err != nil {
+		t.Error(err)
+	}
+	if stats.Candidates < 1 {
+		t.Errorf("Candidates = %d, want at least 1", stats.Candidates)
+	}
+	if stats.MillerRabinRounds != 20*stats.Candidates {
+		t.Errorf("MillerRabinRounds = %d, want %d", stats.MillerRabinRounds, 20*stats.Candidates)
+	}
+	if stats.Elapsed <= 0 {
+		t.Errorf("Elapsed = %v, want positive", stats.Elapsed)
+	}
+}
@@ -0,0 +1,212 @@
+package elgamal
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// streamChunkSize is the number of plain text bytes sealed in each frame
+// of the streaming encryption.
+const streamChunkSize = 64 * 1024
+
+var ErrStreamTruncated = errors.New("elgamal: encrypted stream is truncated")
+
+// streamNonce returns the AES-GCM nonce of the frame with the given counter.
+// The last byte marks the final frame, so that a stream cannot be truncated
+// at a frame boundary without detection.
+func streamNonce(aead cipher.AEAD, counter uint64, final bool) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-9:], counter)
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// encryptWriter implements the streaming encryption of NewEncryptWriter.
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it
+// and writes the result to w. A random AES-256 session key is encrypted with
+// Elgamal and written first as len(c1) || c1 || len(c2) || c2. The data is
+// then written as frames of at most 64 KiB, each sealed with AES-GCM under
+// the session key and prefixed with its 4-byte big-endian length. Close must
+// be called to write the final frame; it does not close w.
+func NewEncryptWriter(pub *PublicKey, w io.Writer) (io.WriteCloser, error) {
+	aead, header, err := pub.newSessionKey()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:    w,
+		aead: aead,
+		buf:  make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, errors.New("elgamal: write to closed encrypt writer")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		m := copy(ew.buf[len(ew.buf):cap(ew.buf)], p)
+		ew.buf = ew.buf[:len(ew.buf)+m]
+		p = p[m:]
+		n += m
+
+		// a full chunk is only sealed once more data follows,
+		// so that the final frame is never empty unless the
+		// whole stream is empty
+		if len(ew.buf) == cap(ew.buf) && len(p) > 0 {
+			if err := ew.writeFrame(false); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close seals the remaining data as the final frame.
+func (ew *encryptWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+	return ew.writeFrame(true)
+}
+
+// writeFrame seals the buffered data and writes it as a frame.
+func (ew *encryptWriter) writeFrame(final bool) error {
+	nonce := streamNonce(ew.aead, ew.counter, final)
+	ew.counter++
+
+	frame := make([]byte, 4, 4+len(ew.buf)+ew.aead.Overhead())
+	frame = ew.aead.Seal(frame, nonce, ew.buf, nil)
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4))
+	ew.buf = ew.buf[:0]
+
+	_, err := ew.w.Write(frame)
+	return err
+}
+
+// decryptReader implements the streaming decryption of NewDecryptReader.
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	final   bool
+	err     error
+}
+
+// NewDecryptReader returns a reader that decrypts a stream written by
+// NewEncryptWriter from r. The encrypted session key is read and decrypted
+// immediately. Reading returns an error if any frame fails AES-GCM
+// authentication, or ErrStreamTruncated if the stream ends before its
+// final frame.
+func NewDecryptReader(priv *PrivateKey, r io.Reader) (io.Reader, error) {
+	limit := priv.Size()
+	c1, err := readRecord(r, limit)
+	if err == io.EOF {
+		return nil, ErrStreamTruncated
+	}
+	if err != nil {
+		return nil, err
+	}
+	c2, err := readRecord(r, limit)
+	if err == io.EOF {
+		return nil, ErrStreamTruncated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := priv.openSessionKey(c1, c2)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: r, aead: aead}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		dr.err = dr.readFrame()
+	}
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+// readFrame reads and opens the next frame. It returns io.EOF once
+// the final frame has been read and the stream is exhausted.
+func (dr *decryptReader) readFrame() error {
+	if dr.final {
+		if _, err := io.ReadFull(dr.r, make([]byte, 1)); err != io.EOF {
+			return errors.New("elgamal: trailing data after final frame")
+		}
+		return io.EOF
+	}
+
+	frame, err := readRecord(dr.r, streamChunkSize+dr.aead.Overhead())
+	if err == io.EOF {
+		return ErrStreamTruncated
+	}
+	if err != nil {
+		return err
+	}
+
+	// the final flag is not transmitted, so try the
+	// regular frame first and then the final one
+	nonce := streamNonce(dr.aead, dr.counter, false)
+	plain, err := dr.aead.Open(nil, nonce, frame, nil)
+	if err != nil {
+		nonce = streamNonce(dr.aead, dr.counter, true)
+		plain, err = dr.aead.Open(nil, nonce, frame, nil)
+		if err != nil {
+			return err
+		}
+		dr.final = true
+	}
+	dr.counter++
+	dr.buf = plain
+	return nil
+}
+
+// readRecord reads a record written as a 4-byte big-endian length followed
+// by its bytes. It returns an error if the length exceeds limit, and io.EOF
+// only if r is exhausted before the record starts.
+func readRecord(r io.Reader, limit int) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, ErrStreamTruncated
+		}
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if uint64(n) > uint64(limit) {
+		return nil, errors.New("elgamal: encrypted stream record is too large")
+	}
+
+	record := make([]byte, n)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, ErrStreamTruncated
+	}
+	return record, nil
+}
@@ -0,0 +1,73 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+// encryptStream encrypts data with NewEncryptWriter, writing it in pieces
+// of 1000 bytes.
+func encryptStream(t *testing.T, pub *PublicKey, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(pub, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(data) > 0 {
+		n := min(len(data), 1000)
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(priv *PrivateKey, enc []byte) ([]byte, error) {
+	r, err := NewDecryptReader(priv, bytes.NewReader(enc))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	priv := newTestKey(t)
+	for _, n := range []int{0, 1, streamChunkSize, streamChunkSize + 1, 3<<20 + 7} {
+		data := make([]byte, n)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		enc := encryptStream(t, &priv.PublicKey, data)
+		out, err := decryptStream(priv, enc)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Errorf("%d bytes: stream changed in the round trip", n)
+		}
+
+		bad := bytes.Clone(enc)
+		bad[len(bad)-1] ^= 1
+		if _, err := decryptStream(priv, bad); err == nil {
+			t.Errorf("%d bytes: accepted a corrupted frame", n)
+		}
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	priv := newTestKey(t)
+	n := 2*streamChunkSize + 5
+	enc := encryptStream(t, &priv.PublicKey, make([]byte, n))
+	// drop the final frame: its length prefix, 5 bytes and the GCM tag
+	cut := len(enc) - (4 + 5 + 16)
+	if _, err := decryptStream(priv, enc[:cut]); err != ErrStreamTruncated {
+		t.Errorf("got %v, want ErrStreamTruncated", err)
+	}
+}
@@ -0,0 +1,184 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// KeyShare represents a share of the secret exponent x of an Elgamal private
+// key, as produced by SplitPrivateKey. Any T shares out of N are able to
+// decrypt a cipher jointly, while fewer shares reveal nothing about x.
+type KeyShare struct {
+	PublicKey *PublicKey
+	Index     int      // evaluation point i in {1...N}
+	T, N      int      // threshold and total number of shares
+	X         *big.Int // share f(i) mod q of the secret exponent x
+}
+
+// PartialDecryption represents the contribution d = c1^(x_i) mod p of a
+// single key share to the decryption of a cipher.
+type PartialDecryption struct {
+	Index int      // index of the key share
+	D     *big.Int // d = c1^(x_i) mod p
+
+	// PublicKey, T and N are copied from the key share by PartialDecrypt,
+	// so that CombinePartialDecryptions needs no further context.
+	PublicKey *PublicKey
+	T, N      int
+}
+
+// SplitPrivateKey splits the secret exponent x of priv into n shares using
+// Shamir secret sharing over the prime group order q, such that any t of
+// them are required to decrypt.
+func SplitPrivateKey(priv *PrivateKey, t, n int) ([]*KeyShare, error) {
+	if t < 1 || n < t {
+		return nil, errors.New("elgamal: invalid threshold parameters")
+	}
+	q := priv.Order()
+	if big.NewInt(int64(n)).Cmp(q) >= 0 {
+		return nil, errors.New("elgamal: too many shares for group order")
+	}
+
+	// f(z) = x + a_1 z + ... + a_(t-1) z^(t-1) mod q
+	coefficients := make([]*big.Int, t)
+	coefficients[0] = priv.X
+	for i := 1; i < t; i++ {
+		a, err := rand.Int(rand.Reader, q)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = a
+	}
+
+	shares := make([]*KeyShare, n)
+	for i := 1; i <= n; i++ {
+		shares[i-1] = &KeyShare{
+			PublicKey: &priv.PublicKey,
+			Index:     i,
+			T:         t,
+			N:         n,
+			X:         evalPolynomial(coefficients, big.NewInt(int64(i)), q),
+		}
+	}
+	return shares, nil
+}
+
+// evalPolynomial evaluates the polynomial with the given
+// coefficients at z modulo q using Horner's method.
+func evalPolynomial(coefficients []*big.Int, z, q *big.Int) *big.Int {
+	r := new(big.Int)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		r.Mul(r, z)
+		r.Add(r, coefficients[i])
+		r.Mod(r, q)
+	}
+	return r
+}
+
+// PartialDecrypt computes the contribution of the key share to the
+// decryption of the cipher (c1, c2). Only c1 is needed for this step.
+func (ks *KeyShare) PartialDecrypt(c1 []byte) (*PartialDecryption, error) {
+	cipher1 := new(big.Int).SetBytes(c1)
+	if cipher1.Cmp(ks.PublicKey.P) >= 0 { //  c1 < P
+		return nil, ErrCipherLarge
+	}
+
+	// d = c1^(x_i) mod p
+	return &PartialDecryption{
+		Index:     ks.Index,
+		D:         new(big.Int).Exp(cipher1, ks.X, ks.PublicKey.P),
+		PublicKey: ks.PublicKey,
+		T:         ks.T,
+		N:         ks.N,
+	}, nil
+}
+
+// CombineShares recovers the plain text of a cipher from the partial
+// decryptions of at least T distinct key shares. The shared secret
+// s = c1^x mod p is reconstructed by Lagrange interpolation in the
+// exponent, and the plain text is m = c2 * s^(-1) mod p. With fewer than
+// T partial decryptions the recovered plain text is meaningless.
+func CombineShares(pub *PublicKey, partials []*PartialDecryption, c2 []byte) ([]byte, error) {
+	cipher2 := new(big.Int).SetBytes(c2)
+	if cipher2.Cmp(pub.P) >= 0 { //  c2 < P
+		return nil, ErrCipherLarge
+	}
+	if len(partials) == 0 {
+		return nil, errors.New("elgamal: no partial decryptions")
+	}
+
+	q := pub.Order()
+	indices := make([]*big.Int, len(partials))
+	seen := make(map[int]bool, len(partials))
+	for i, pd := range partials {
+		if pd.Index < 1 || seen[pd.Index] {
+			return nil, errors.New("elgamal: invalid or duplicate share index")
+		}
+		seen[pd.Index] = true
+		indices[i] = big.NewInt(int64(pd.Index))
+	}
+
+	// s = prod(d_i^lambda_i) mod p
+	s := new(big.Int).Set(one)
+	for i, pd := range partials {
+		lambda := lagrangeAtZero(indices, i, q)
+		s.Mod(s.Mul(s, new(big.Int).Exp(pd.D, lambda, pub.P)), pub.P)
+	}
+
+	// s = s(inv) = s^(-1) mod p
+	if s.ModInverse(s, pub.P) == nil {
+		return nil, errors.New("elgamal: invalid partial decryptions")
+	}
+	// m = s(inv) * c2 mod p
+	m := new(big.Int).Mod(new(big.Int).Mul(s, cipher2), pub.P)
+	return m.Bytes(), nil
+}
+
+// CombinePartialDecryptions is like CombineShares, but it takes the public
+// key and the threshold from the partial decryptions, as set by
+// PartialDecrypt. Unlike CombineShares, it returns an error if fewer than T
+// partial decryptions are passed, if an index is outside of {1...N} or
+// duplicate, or if the partial decryptions belong to different keys.
+func CombinePartialDecryptions(partials []PartialDecryption, c2 []byte) ([]byte, error) {
+	if len(partials) == 0 {
+		return nil, errors.New("elgamal: no partial decryptions")
+	}
+	first := &partials[0]
+	if first.PublicKey == nil {
+		return nil, errors.New("elgamal: partial decryption without public key")
+	}
+
+	ptrs := make([]*PartialDecryption, len(partials))
+	for i := range partials {
+		pd := &partials[i]
+		if pd.T != first.T || pd.N != first.N || !first.PublicKey.Equal(pd.PublicKey) {
+			return nil, errors.New("elgamal: partial decryptions of different keys")
+		}
+		if pd.Index > pd.N {
+			return nil, errors.New("elgamal: invalid or duplicate share index")
+		}
+		ptrs[i] = pd
+	}
+	if len(partials) < first.T {
+		return nil, errors.New("elgamal: not enough partial decryptions")
+	}
+	return CombineShares(first.PublicKey, ptrs, c2)
+}
+
+// lagrangeAtZero returns the Lagrange coefficient of the i-th index at
+// zero, prod(j / (j - i)) over all other indices j, modulo q.
+func lagrangeAtZero(indices []*big.Int, i int, q *big.Int) *big.Int {
+	num := new(big.Int).Set(one)
+	den := new(big.Int).Set(one)
+	for j, xj := range indices {
+		if j == i {
+			continue
+		}
+		num.Mod(num.Mul(num, xj), q)
+		den.Mod(den.Mul(den, new(big.Int).Sub(xj, indices[i])), q)
+	}
+	// lambda = num * den^(-1) mod q
+	den.ModInverse(den, q)
+	return num.Mod(num.Mul(num, den), q)
+}
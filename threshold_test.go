@@ -0,0 +1,122 @@
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+)
+
+// subsets returns all subsets of {0...(n-1)} with k elements.
+func subsets(n, k int) [][]int {
+	if k == 0 {
+		return [][]int{nil}
+	}
+	var all [][]int
+	for i := k - 1; i < n; i++ {
+		for _, s := range subsets(i, k-1) {
+			all = append(all, append(s, i))
+		}
+	}
+	return all
+}
+
+func TestThresholdDecryption(t *testing.T) {
+	priv := newTestKey(t)
+	shares, err := SplitPrivateKey(priv, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("threshold")
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partials := make([]*PartialDecryption, len(shares))
+	for i, ks := range shares {
+		if partials[i], err = ks.PartialDecrypt(c1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, set := range subsets(5, 3) {
+		var pds []*PartialDecryption
+		for _, i := range set {
+			pds = append(pds, partials[i])
+		}
+		m, err := CombineShares(&priv.PublicKey, pds, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Errorf("shares %v: got %q, want %q", set, m, msg)
+		}
+	}
+	for _, set := range subsets(5, 2) {
+		m, err := CombineShares(&priv.PublicKey, []*PartialDecryption{partials[set[0]], partials[set[1]]}, c2)
+		if err == nil && bytes.Equal(m, msg) {
+			t.Errorf("shares %v below the threshold recovered the message", set)
+		}
+	}
+	if _, err := CombineShares(&priv.PublicKey, []*PartialDecryption{partials[0], partials[0], partials[1]}, c2); err == nil {
+		t.Error("accepted a duplicate share")
+	}
+}
+
+func TestCombinePartialDecryptions(t *testing.T) {
+	priv := newTestKey(t)
+	shares, err := SplitPrivateKey(priv, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("threshold")
+	c1, c2, err := priv.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partials := make([]PartialDecryption, len(shares))
+	for i, ks := range shares {
+		pd, err := ks.PartialDecrypt(c1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials[i] = *pd
+	}
+
+	for _, subset := range subsets(5, 3) {
+		var pds []PartialDecryption
+		for _, i := range subset {
+			pds = append(pds, partials[i])
+		}
+		m, err := CombinePartialDecryptions(pds, c2)
+		if err != nil {
+			t.Fatalf("shares %v: %v", subset, err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Errorf("shares %v: got %q, want %q", subset, m, msg)
+		}
+	}
+	for _, subset := range subsets(5, 2) {
+		pds := []PartialDecryption{partials[subset[0]], partials[subset[1]]}
+		if _, err := CombinePartialDecryptions(pds, c2); err == nil {
+			t.Errorf("shares %v: combined fewer than T partial decryptions", subset)
+		}
+	}
+
+	outOfRange := partials[2]
+	outOfRange.Index = 9
+	other := newTestKey(t)
+	foreign := partials[2]
+	foreign.PublicKey = &other.PublicKey
+	for _, tt := range []struct {
+		name string
+		pds  []PartialDecryption
+	}{
+		{"no partial decryptions", nil},
+		{"duplicate index", []PartialDecryption{partials[0], partials[0], partials[1]}},
+		{"index outside of {1...N}", []PartialDecryption{partials[0], partials[1], outOfRange}},
+		{"different keys", []PartialDecryption{partials[0], partials[1], foreign}},
+	} {
+		if _, err := CombinePartialDecryptions(tt.pds, c2); err == nil {
+			t.Errorf("accepted %s", tt.name)
+		}
+	}
+}
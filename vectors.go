@@ -0,0 +1,106 @@
+package elgamal
+
+import "math/big"
+
+// TestVector is a known answer vector of Elgamal encryption. Encrypting
+// Plaintext with EncryptWithK under the public key <P,G,Y> and the integer
+// K yields the cipher (C1, C2), which decrypts to Plaintext again under the
+// secret exponent X.
+type TestVector struct {
+	P, G, Y, X *big.Int
+	Plaintext  []byte
+	K          *big.Int
+	C1, C2     []byte
+}
+
+// knownAnswerVectors holds the vectors of KnownAnswerVectors in the
+// 2048-bit MODP Group, with X and K derived from SHA-256 of fixed labels.
+var knownAnswerVectors = []struct {
+	plaintext       string
+	x, k, y, c1, c2 string
+}{
+	{
+		plaintext: "elgamal known answer vector 1",
+		x:         "DAB0D80FE1432EA4CB2628B0BE888B1A4F3D38FECD15677BBFA198101EA66F29",
+		k:         "DBDF8711675C68B2DEAF6751612F21E4DB9A460880680ED6ED239E2EEB205567",
+		y: "E65890ECCE3A28EE955FAA50957ACE259EFD8FAB23707B746B2FD46EAB3692DA" +
+			"2C2901FECBD07366EBE73CDC457C5D27942515C575BA53753E92E4936B7AEFB4" +
+			"AF7D84D74CF2800227ABDD51BC6FDE7709EF125BE5EDE1B9C55CC279825A5E69" +
+			"24781771318B8F9F54FDB730670E7968455E25E74F9BB23CB94A9C550CBBC556" +
+			"D1312E94535E3B5DD9335F809751E4387A8FEBC59099A744FED907E8D21FE5ED" +
+			"BE53277C33DE77209AD68C63B497E5D901F487B9EA38C5A868B159D37B02C948" +
+			"76033DCEF16D96D036ADA0DAB2F923DA8B5274933CFD236EA7D4E8466BE64CEA" +
+			"88A2B703EABCA3AF1FF3DE3678E1483FD5049F7528257E988C8662F48D558046",
+		c1: "08A2EBD0F591126E773CB9688C7D7C310657E12C12D601488BA44BB5C905B302" +
+			"C97818596B8303F4C25FFF452331D6E5BA0EBF6BC76385105E60B649C6F7BE3A" +
+			"895CFF793722439C32AC38ED83751286CCAF3540B852DE97897795C18633F448" +
+			"B8F67AF869E874E284DDAD58374F18BE3F4B3303E006AF13281921884A71DDDF" +
+			"82187015461CE1D9AD8F54DFB4F624C969B4F7E8E4EFD850FC57039B1BECBE98" +
+			"EB0A02B7B03A5D2369EB88C26A1B1035A63B95593752771D84061DD207793002" +
+			"03F4CE4B944998084BAF8B02EC7986FDAD91304610A0BD594139B5870F7533EB" +
+			"A173DB2984BCADE568EC6BEB5F401A86AB8FF7C4209EF25BD0CF715EC82FCC56",
+		c2: "234AFB3BEC7673178E64664F6031BE98C871623BD8CBD7C2DEDA842267E46389" +
+			"9D0A389741D09218375E3FBB6FE7BC2E043DFF6D00E2C684EE55F4E98A1ACE24" +
+			"B5FB2711A9B86E9B40C65B60C678FD95DAAEF9A26924475BEE236C23F87BB0DD" +
+			"66CE8FDFE6E684EE6C6BCCB661D06ADE46543CCF343920BC123D680AE8DF56CC" +
+			"F8FA1008C93BF0825251F58820BE32B2ADD3F6345812258D49E3D6FE2455570B" +
+			"C2392AC0A5390061A90E6681EFC87CAE63DFE2BB29C8D3BA1FF9C6AC4D4B1CC7" +
+			"40CC202D3C2ADC91917272AEA5BAF5BFE6B70EABB960B64EC60AB5E9CD264E61" +
+			"ABBA57EF2F8D697D3F55DAA96D4A9080049BECD56CBCF0988616675E35FF13E5",
+	},
+	{
+		plaintext: "\x01 elgamal known answer vector 2",
+		x:         "E2DCA4AA94BEF29B43BA3AEE9552BE146C5E05ADDF4B347B6A7C0F493672894C",
+		k:         "42C86843439E0D6AF2CB728199D9BCC3F1574A7A3ACD9AF1CD063F341111D852",
+		y: "1FA6C6B6C2C035EC6A5770ED8775EDADDF22797FB63B207DD02E915C80B9BFF2" +
+			"650C831C19B0EC00B93FE9384BCD914A2C7896C21D443E8AD6919862A0C10988" +
+			"98EC242F1516B92A57FF45752B449CE347F31AE91949E3589C33AA7068B141CA" +
+			"B11AF62F9A4FC014DB29A3D49B838C1458D390353A1E566B20F7D38AC4A8B9E3" +
+			"2F6B0CFBD7FD8F0BC90F08AF8F29937298C6A877CFC485DBA0FB58E60F7EFF3F" +
+			"D3B9F0D98439D9440F8BD1F81D6D2E15AB4064EE169AFED7F215BB4E79EBA586" +
+			"E9704C21370FA09C8D00C10F9613DC473AE0C896D2C64D063804695AC9CC53E4" +
+			"33D7E96565035B797FD43B78797D3C32D79D5C8E8F30765B2FBBF99CBA0C8E2F",
+		c1: "7EE3F425DFD3760F6CC6325776B08D46B7F51ED16317B317870EC3503AABEEAD" +
+			"B36C667D8F953C4EE4A020B244BD5C2DFEA16C646ED569012FE68F67AABC45A2" +
+			"EB9BBECABDA26EF3D09748CB98643499A3330A24F912CE56E5E5BAEA78AA8DCA" +
+			"ABC3011A3091D84B73630596B365374CE74295D23414F4E1364BEC4F904770A8" +
+			"F49FA3D91E7CBD9B4EF9F8130FE9375D0EE69DEB6F2BCA1B565DFCD30D021471" +
+			"98CAC2B4AA56896104DF470D683B98D10D8776B4B0CA0FF502C853E4EA896FB1" +
+			"37FBBF68CE27857B0182949002B1CE09A86D66B15B159C42B680C886427D01E3" +
+			"79866A1FC47BE780FA56EC1A755D2EFB9A95DB8CFCC8FD7F4CF77EE163F35B15",
+		c2: "3E27EE348EF0958A3207DF6DD20D300A45515A2F594D24EC5CAF282B5B860EED" +
+			"0A3F1A0AF11BD0D6B505C8359F59274A8F1557561C904576E36AB843BDB9F866" +
+			"AF6F4BC58BB86F97BAEAEB950D99187AE62470249DC3B1F4C55A72F28C66EC39" +
+			"A4D274276E0686B6542A28CC66D3407621AA536C7DB2693BCEE1224C1E365BF3" +
+			"67711E808712D53BE925866850B7617556C7658B75ACB8A6880987FB0959357B" +
+			"48EF88A829A996B1DD0C4D19ED7E76B0C370AADDEC8B1589536211D1909FD5DC" +
+			"95724572C7628FA70DAE4DC58185DFC6C4D059032689253602C35FACEF05BF72" +
+			"2291E43F730D2F820BB4128E11AAC20C1C3AECCF4D1D7B4786C2EB3115153E31",
+	},
+}
+
+// KnownAnswerVectors returns deterministic known answer vectors, which
+// downstream projects may use to verify their wrappers of this package.
+// Every call returns fresh copies of the vectors.
+func KnownAnswerVectors() []TestVector {
+	vectors := make([]TestVector, len(knownAnswerVectors))
+	for i, v := range knownAnswerVectors {
+		vectors[i] = TestVector{
+			P:         MODP2048.P(),
+			G:         MODP2048.G(),
+			Y:         hexInt(v.y),
+			X:         hexInt(v.x),
+			Plaintext: []byte(v.plaintext),
+			K:         hexInt(v.k),
+			C1:        hexInt(v.c1).Bytes(),
+			C2:        hexInt(v.c2).Bytes(),
+		}
+	}
+	return vectors
+}
+
+// hexInt parses the hex constant s of the vectors.
+func hexInt(s string) *big.Int {
+	x, _ := new(big.Int).SetString(s, 16)
+	return x
+}
@@ -0,0 +1,39 @@
+package elgamal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKnownAnswerVectors(t *testing.T) {
+	vectors := KnownAnswerVectors()
+	if len(vectors) == 0 {
+		t.Fatal("no vectors")
+	}
+	for i, v := range vectors {
+		priv := &PrivateKey{PublicKey: PublicKey{P: v.P, G: v.G, Y: v.Y}, X: v.X}
+		if err := priv.Validate(); err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		c1, c2, err := priv.EncryptWithK(v.Plaintext, v.K)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		if !bytes.Equal(c1, v.C1) || !bytes.Equal(c2, v.C2) {
+			t.Errorf("vector %d: cipher mismatch", i)
+		}
+		m, err := priv.Decrypt(v.C1, v.C2)
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		if !bytes.Equal(m, v.Plaintext) {
+			t.Errorf("vector %d: got %q, want %q", i, m, v.Plaintext)
+		}
+	}
+
+	// every call returns fresh copies
+	vectors[0].P.SetInt64(1)
+	if KnownAnswerVectors()[0].P.Cmp(MODP2048.P()) != 0 {
+		t.Error("vectors share state between calls")
+	}
+}